@@ -0,0 +1,55 @@
+package ytdlp
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+type ArchiveFormat int
+
+const (
+	ArchiveZip ArchiveFormat = iota
+	ArchiveTar
+)
+
+// DownloadToArchive downloads url into a temporary directory and writes the media and
+// its info JSON, thumbnail and subtitles to w as a zip or tar archive. args are passed
+// after the defaults, so e.g. --no-write-thumbnail disables a sidecar. The temporary
+// directory is removed afterwards.
+func (inst YTDLPInstance) DownloadToArchive(url string, args []string, w io.Writer, format ArchiveFormat) error {
+	if format != ArchiveZip && format != ArchiveTar {
+		return fmt.Errorf("unsupported archive format: %d", format)
+	}
+	dir, err := os.MkdirTemp("", "ytdlp-archive-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	args = append([]string{"--write-info-json", "--write-thumbnail", "--write-subs"}, args...)
+	if err := inst.Execute(url, append(args, "-P", dir)...); err != nil {
+		return err
+	}
+	if format == ArchiveZip {
+		return writeZip(dir, w)
+	}
+	return writeTar(dir, w)
+}
+
+func writeZip(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	if err := zw.AddFS(os.DirFS(dir)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeTar(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	if err := tw.AddFS(os.DirFS(dir)); err != nil {
+		return err
+	}
+	return tw.Close()
+}
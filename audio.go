@@ -0,0 +1,114 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+type AudioFormat string
+
+const (
+	AudioMP3    AudioFormat = "mp3"
+	AudioM4A    AudioFormat = "m4a"
+	AudioAAC    AudioFormat = "aac"
+	AudioOpus   AudioFormat = "opus"
+	AudioVorbis AudioFormat = "vorbis"
+	AudioFLAC   AudioFormat = "flac"
+	AudioWAV    AudioFormat = "wav"
+)
+
+const defaultMaxAudioBytes = 64 << 20
+
+// audioEncoders holds the ffmpeg muxer and codec arguments for each format. The
+// muxers must be able to write to a pipe.
+var audioEncoders = map[AudioFormat][]string{
+	AudioMP3:    {"-f", "mp3", "-c:a", "libmp3lame"},
+	AudioM4A:    {"-f", "ipod", "-c:a", "aac", "-movflags", "frag_keyframe+empty_moov"},
+	AudioAAC:    {"-f", "adts", "-c:a", "aac"},
+	AudioOpus:   {"-f", "opus", "-c:a", "libopus"},
+	AudioVorbis: {"-f", "ogg", "-c:a", "libvorbis"},
+	AudioFLAC:   {"-f", "flac", "-c:a", "flac"},
+	AudioWAV:    {"-f", "wav", "-c:a", "pcm_s16le"},
+}
+
+// WithMaxAudioBytes limits the size of the audio returned by ExtractAudioBytes. The
+// default is 64 MiB.
+func WithMaxAudioBytes(n int64) Option {
+	return func(inst *YTDLPInstance) error {
+		if n <= 0 {
+			return errors.New("max audio bytes must be positive")
+		}
+		inst.maxAudioBytes = n
+		return nil
+	}
+}
+
+// ExtractAudioBytes downloads the best audio of url and returns it converted to format,
+// without writing any files. The audio is streamed from yt-dlp through ffmpeg, which
+// must be installed. It fails once the output grows past the limit set with
+// WithMaxAudioBytes, so it is meant for short clips.
+func (inst YTDLPInstance) ExtractAudioBytes(ctx context.Context, url string, format AudioFormat) ([]byte, error) {
+	encoder, ok := audioEncoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported audio format: %s", format)
+	}
+	ffmpeg, err := findFFmpeg(inst.ffmpegLocation)
+	if err != nil {
+		return nil, errors.New("extracting audio requires ffmpeg, but it was not found")
+	}
+	limit := inst.maxAudioBytes
+	if limit == 0 {
+		limit = defaultMaxAudioBytes
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r, err := inst.stream(ctx, url, []string{"-f", "ba/b", "--no-playlist"})
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-v", "error", "-i", "pipe:0", "-vn"}, encoder...)
+	cmd := exec.CommandContext(ctx, ffmpeg, append(args, "pipe:1")...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	wait, err := inst.pipeFFmpeg(cmd, r)
+	if err != nil {
+		return nil, err
+	}
+	out, readErr := io.ReadAll(io.LimitReader(stdout, limit+1))
+	if int64(len(out)) > limit {
+		// stops both processes, so wait returns promptly
+		cancel()
+		_ = wait()
+		return nil, fmt.Errorf("audio exceeds %d bytes", limit)
+	}
+	if err := wait(); err != nil {
+		return nil, errors.New("ffmpeg error: " + err.Error() + " | " + stderr.String())
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return out, nil
+}
+
+// DownloadTrack downloads the best audio of url into outDir with its metadata and cover
+// art embedded, for music sites like SoundCloud or Bandcamp. Album and set URLs
+// download every track. extraArgs are passed last and override the preset.
+func (inst YTDLPInstance) DownloadTrack(url, outDir string, extraArgs ...string) error {
+	if outDir == "" {
+		return errors.New("empty output directory")
+	}
+	_, err := inst.ExecuteWithOptions(url, DownloadOptions{
+		EmbedMetadata: true,
+		ExtraArgs:     append([]string{"-f", "ba/b", "--embed-thumbnail", "-P", outDir}, extraArgs...),
+	})
+	return err
+}
@@ -0,0 +1,70 @@
+package ytdlp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script standing in for yt-dlp or ffmpeg.
+func writeScript(t *testing.T, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractAudioBytes(t *testing.T) {
+	const destination = "echo '[download] Destination: -' >&2\n"
+	tests := []struct {
+		name    string
+		ytdlp   string
+		ffmpeg  string
+		want    string
+		wantErr string
+	}{
+		{"converted", destination + "printf audio", "cat", "audio", ""},
+		{"size cap", destination + "exec cat /dev/zero", "cat", "", "audio exceeds 1024 bytes"},
+		{"ffmpeg error", destination + "exec cat /dev/zero", "head -c 10 >/dev/null; echo broken >&2; exit 1", "", "broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ytdlp := writeScript(t, "yt-dlp", tt.ytdlp)
+			ffmpeg := writeScript(t, "ffmpeg", tt.ffmpeg)
+			observed := make(chan InvocationResult, 2)
+			inst, err := NewInstance(ytdlp, WithFFmpegLocation(ffmpeg), WithMaxAudioBytes(1024),
+				WithObserver(func(r InvocationResult) { observed <- r }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := inst.ExtractAudioBytes(context.Background(), "https://example.com/v", AudioMP3)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(out) != tt.want {
+					t.Errorf("ExtractAudioBytes() = %q, want %q", out, tt.want)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ExtractAudioBytes() error = %v, want %q", err, tt.wantErr)
+			}
+			// both yt-dlp and ffmpeg must have exited
+			for range 2 {
+				select {
+				case <-observed:
+				case <-time.After(5 * time.Second):
+					t.Fatal("process still running after ExtractAudioBytes returned")
+				}
+			}
+		})
+	}
+}
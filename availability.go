@@ -0,0 +1,28 @@
+package ytdlp
+
+// Availability tells who can watch a video, as reported by yt-dlp.
+type Availability string
+
+const (
+	AvailabilityPublic         Availability = "public"
+	AvailabilityUnlisted       Availability = "unlisted"
+	AvailabilityNeedsAuth      Availability = "needs_auth"
+	AvailabilitySubscriberOnly Availability = "subscriber_only"
+	AvailabilityPremiumOnly    Availability = "premium_only"
+	AvailabilityPrivate        Availability = "private"
+	// AvailabilityUnknown is returned when the extractor does not report availability.
+	AvailabilityUnknown Availability = ""
+)
+
+// GetAvailability returns the availability of the video at url, so callers can skip
+// videos their credentials cannot access. Videos yt-dlp cannot extract at all, such as
+// private ones without access, make it fail instead.
+func (inst YTDLPInstance) GetAvailability(url string) (Availability, error) {
+	var info struct {
+		Availability Availability `json:"availability"`
+	}
+	if err := inst.dumpJSON(url, &info, "--skip-download", "--no-playlist"); err != nil {
+		return AvailabilityUnknown, err
+	}
+	return info.Availability, nil
+}
@@ -0,0 +1,106 @@
+package ytdlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// ExecuteBatchFile runs yt-dlp on the URLs listed in the batch file at path, one per line.
+func (inst YTDLPInstance) ExecuteBatchFile(path string, args ...string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("invalid batch file: %v", err)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid batch file: %v", err)
+	}
+	cmd := inst.command(context.Background(), append([]string{"-a", path}, args...)...)
+	out, err := inst.combinedOutput(cmd)
+	if err != nil {
+		return errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + string(out))
+	}
+	return nil
+}
+
+// WriteBatchFile writes urls to a temporary batch file for ExecuteBatchFile. cleanup
+// removes the file.
+func WriteBatchFile(urls []string) (path string, cleanup func(), err error) {
+	f, err := os.CreateTemp("", "ytdlp-batch-*.txt")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() {
+		_ = os.Remove(f.Name())
+	}
+	for _, u := range urls {
+		if strings.ContainsAny(u, "\r\n") {
+			f.Close()
+			cleanup()
+			return "", nil, fmt.Errorf("invalid url in batch: %q", u)
+		}
+	}
+	_, err = f.WriteString(strings.Join(urls, "\n") + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+// ExecuteURLs runs yt-dlp on all urls at once. If the command line would exceed the
+// operating system's argument length limit, the URLs are passed through a temporary
+// batch file instead.
+func (inst YTDLPInstance) ExecuteURLs(urls []string, args ...string) error {
+	if len(urls) == 0 {
+		return errors.New("no urls")
+	}
+	for _, u := range urls {
+		if err := inst.checkURL(u); err != nil {
+			return err
+		}
+	}
+	if !argvTooLong(inst.bPath, append(slices.Concat(inst.args, args), urls...)) {
+		cmd := inst.command(context.Background(), append(slices.Clone(args), urls...)...)
+		out, err := inst.combinedOutput(cmd)
+		if err != nil {
+			return errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + string(out))
+		}
+		return nil
+	}
+	path, cleanup, err := WriteBatchFile(urls)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return inst.ExecuteBatchFile(path, args...)
+}
+
+// argvTooLong conservatively estimates whether bin and args exceed the argument length
+// limit: 32K characters for the whole command line on Windows, and a fraction of the
+// typical ARG_MAX, which also has to hold the environment, elsewhere.
+func argvTooLong(bin string, args []string) bool {
+	limit := 256 << 10
+	size := 0
+	if runtime.GOOS == "windows" {
+		limit = 32000
+	} else {
+		for _, e := range os.Environ() {
+			size += len(e) + 1
+		}
+	}
+	size += len(bin) + 1
+	for _, a := range args {
+		// leave room for quoting on Windows
+		size += len(a) + 3
+	}
+	return size > limit
+}
@@ -0,0 +1,23 @@
+package ytdlp
+
+import "slices"
+
+// WithNoCacheDir disables yt-dlp's filesystem cache, which holds extractor data such as
+// YouTube signature functions. Use it with Clone for single calls that fail on stale
+// cache entries, or call ClearCache to fix the cache for every call.
+func WithNoCacheDir() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.args = append(inst.args, "--no-cache-dir")
+		return nil
+	}
+}
+
+// ClearCache deletes all of yt-dlp's cached extractor data.
+func (inst YTDLPInstance) ClearCache() error {
+	// yt-dlp ignores --rm-cache-dir when the cache is disabled
+	inst.args = slices.DeleteFunc(slices.Clone(inst.args), func(arg string) bool {
+		return arg == "--no-cache-dir"
+	})
+	_, err := inst.output("--rm-cache-dir")
+	return err
+}
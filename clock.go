@@ -0,0 +1,47 @@
+package ytdlp
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock abstracts time so the timings the library reports, such as
+// InvocationResult.Start and DownloadResult.Elapsed, and its timeouts, such as the
+// grace period before a cancelled process is killed, can be controlled in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	// AfterFunc calls f in its own goroutine once d has elapsed, like time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending call scheduled with Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call and reports whether it was still pending.
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// WithClock replaces the clock used for timing; the default is the system clock.
+func WithClock(c Clock) Option {
+	return func(inst *YTDLPInstance) error {
+		if c == nil {
+			return errors.New("nil clock")
+		}
+		inst.clock = c
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package ytdlp
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// CountingReader counts the bytes read through it, e.g. from the reader returned by
+// ExecuteStream, which is useful when yt-dlp cannot report a percentage (live streams).
+type CountingReader struct {
+	r        io.Reader
+	n        atomic.Int64
+	interval time.Duration
+	last     time.Time
+	fn       func(n int64)
+	clock    Clock
+}
+
+// NewCountingReader wraps r. If fn is not nil it is called with the total byte count at
+// most once per interval, and once more when r returns an error such as io.EOF.
+func NewCountingReader(r io.Reader, interval time.Duration, fn func(n int64)) *CountingReader {
+	return &CountingReader{r: r, interval: interval, fn: fn, clock: systemClock{}}
+}
+
+// WithClock replaces the clock that paces the calls to fn and returns c. It must be
+// called before the first Read.
+func (c *CountingReader) WithClock(clock Clock) *CountingReader {
+	c.clock = clock
+	return c
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	total := c.n.Add(int64(n))
+	if c.fn != nil {
+		now := c.clock.Now()
+		if err != nil || now.Sub(c.last) >= c.interval {
+			c.last = now
+			c.fn(total)
+		}
+	}
+	return n, err
+}
+
+// Count returns the number of bytes read so far. It is safe to call concurrently with Read.
+func (c *CountingReader) Count() int64 {
+	return c.n.Load()
+}
@@ -0,0 +1,42 @@
+package ytdlp
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestCountingReaderInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1e9, 0)}
+	var calls []int64
+	r := NewCountingReader(iotest.OneByteReader(strings.NewReader("abcdef")), time.Second, func(n int64) {
+		calls = append(calls, n)
+	}).WithClock(clock)
+	buf := make([]byte, 1)
+	read := func() {
+		t.Helper()
+		if _, err := r.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read() // the first read always reports
+	read()
+	read()
+	clock.Advance(999 * time.Millisecond)
+	read()
+	clock.Advance(time.Millisecond)
+	read()
+	read()
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 5, 6}; !slices.Equal(calls, want) {
+		t.Errorf("fn called with %v, want %v", calls, want)
+	}
+	if r.Count() != 6 {
+		t.Errorf("Count() = %d, want 6", r.Count())
+	}
+}
@@ -0,0 +1,39 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// CheckDiskSpace returns an error wrapping ErrInsufficientSpace if the filesystem
+// holding path has less than requiredBytes available. path does not need to exist yet;
+// its closest existing parent is checked instead. Combine with EstimateSize to preflight
+// downloads.
+func CheckDiskSpace(path string, requiredBytes int64) error {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing directory for %s", path)
+		}
+		dir = parent
+	}
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		return err
+	}
+	if requiredBytes > 0 && free < uint64(requiredBytes) {
+		return fmt.Errorf("%w: %d bytes required, %d available", ErrInsufficientSpace, requiredBytes, free)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package ytdlp
+
+import (
+	"errors"
+	"runtime"
+)
+
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errors.New("disk space check is not supported on " + runtime.GOOS)
+}
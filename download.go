@@ -0,0 +1,496 @@
+package ytdlp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+var remuxTargets = []string{"avi", "flv", "mkv", "mov", "mp4", "webm", "aac", "aiff", "alac", "flac", "m4a", "mka", "mp3", "ogg", "opus", "vorbis", "wav"}
+
+var embedSubsContainers = []string{"mp4", "webm", "mkv"}
+
+var videoTargets = []string{"avi", "flv", "gif", "mkv", "mov", "mp4", "webm", "aac", "aiff", "alac", "flac", "m4a", "mka", "mp3", "ogg", "opus", "vorbis", "wav"}
+
+var itemErrorRegexp = regexp.MustCompile(`^ERROR: \[([^\]]+)\] ([^:\s]+): (.*)$`)
+
+const (
+	destinationPrefix  = "[download] Destination: "
+	skipFragmentPrefix = "[download] Skipping fragment "
+	resumePrefix       = "[download] Resuming download at byte "
+)
+
+var artifactRegexp = regexp.MustCompile(`^\[\w+\] (?:Destination: (.+)|Writing .+? to: (.+)|Merging formats into "(.+)"|(.+) has already been downloaded)$`)
+
+var dateSpecRegexp = regexp.MustCompile(`^(\d{8}|(now|today|yesterday)([+-]\d+(day|week|month|year)s?)?)$`)
+
+type DownloadOptions struct {
+	// RecodeVideo re-encodes the video into the given container/codec (requires ffmpeg).
+	RecodeVideo string
+	// RemuxVideo changes the container without re-encoding where possible (requires
+	// ffmpeg). It is faster and lossless compared to RecodeVideo; set only one of them.
+	RemuxVideo string
+	// DateAfter and DateBefore limit downloads to videos uploaded on or after/before the date.
+	DateAfter  time.Time
+	DateBefore time.Time
+	// DateAfterSpec and DateBeforeSpec take a yt-dlp date spec (YYYYMMDD or e.g. "now-1week")
+	// and are mutually exclusive with their time.Time counterparts.
+	DateAfterSpec  string
+	DateBeforeSpec string
+	// NoPart writes directly to the output file instead of a .part file.
+	NoPart bool
+	// NoContinue restarts partially downloaded files from the beginning.
+	NoContinue bool
+	// NoMtime leaves the file modification time at the download time.
+	NoMtime bool
+	// AbortOnError stops at the first failing item of a playlist or batch instead of
+	// continuing with the remaining items.
+	AbortOnError bool
+	// SleepInterval is the time to sleep before each download. If MaxSleepInterval is
+	// also set, a random duration between the two is used.
+	SleepInterval    time.Duration
+	MaxSleepInterval time.Duration
+	// SleepRequests is the time to sleep between requests during extraction.
+	SleepRequests time.Duration
+	// EmbedSubs embeds subtitles into the video, which makes yt-dlp download them as
+	// well. Only mp4, webm and mkv support embedded subtitles; other recode or remux
+	// targets are reported in DownloadResult.Warnings.
+	EmbedSubs bool
+	// PlaylistReverse and PlaylistRandom change the order playlist items are downloaded
+	// in. They are mutually exclusive.
+	PlaylistReverse bool
+	PlaylistRandom  bool
+	// PlaylistItems selects playlist items with a --playlist-items spec, see
+	// ParsePlaylistItems.
+	PlaylistItems string
+	// ForceOverwrites overwrites existing video and metadata files, NoOverwrites never
+	// overwrites any file. They are mutually exclusive; by default only metadata files
+	// are overwritten.
+	ForceOverwrites bool
+	NoOverwrites    bool
+	// Sidecar files written next to the media, see also ArchiveBundle.
+	WriteInfoJSON    bool
+	WriteDescription bool
+	WriteThumbnail   bool
+	WriteSubs        bool
+	// EmbedMetadata embeds metadata and chapters into the media file.
+	EmbedMetadata bool
+	// SkipUnavailableFragments continues HLS/DASH downloads past missing fragments, which
+	// produces a playable but incomplete file; see DownloadResult.SkippedFragments.
+	// AbortOnUnavailableFragment fails instead. They are mutually exclusive.
+	SkipUnavailableFragments   bool
+	AbortOnUnavailableFragment bool
+	// NoMerge downloads the best video-only and best audio-only formats as two separate
+	// files ("-f bv,ba", within the WithMinHeight and WithMaxHeight bounds) instead of
+	// merging them; both paths are listed in DownloadResult.Files. Both files share the
+	// output template, so include %(format_id)s in it to keep them from colliding. A "-f" with a comma-separated
+	// selector in ExtraArgs replaces the default selection.
+	NoMerge bool
+	// WriteLink writes an internet shortcut file for the current platform; the other
+	// Write*Link fields force a .url, .webloc or .desktop shortcut respectively.
+	WriteLink        bool
+	WriteURLLink     bool
+	WriteWeblocLink  bool
+	WriteDesktopLink bool
+	// ExtractAudio converts the download to an audio file of the given format (requires
+	// ffmpeg). It cannot be combined with RecodeVideo or RemuxVideo.
+	ExtractAudio AudioFormat
+	// Quiet and Verbose change how much yt-dlp logs. They are mutually exclusive. Quiet
+	// also suppresses the lines OnProgress and OnDestination are called for.
+	Quiet   bool
+	Verbose bool
+	// ForceIPv4 and ForceIPv6 make all connections use one IP version. They are
+	// mutually exclusive.
+	ForceIPv4 bool
+	ForceIPv6 bool
+	// ExtraArgs are passed after all generated arguments. Since yt-dlp lets later
+	// flags win, they can also override what the typed fields generate.
+	ExtraArgs []string
+	// OnProgress is called for every progress line yt-dlp prints.
+	OnProgress func(DownloadProgress)
+	// OnDestination is called with the output path as soon as yt-dlp announces it,
+	// before the download has finished. It may be called once per downloaded format.
+	OnDestination func(path string)
+}
+
+// ArchiveBundle returns opts with info JSON, description, thumbnail and subtitle files
+// and embedded metadata enabled, for archiving everything about a video. The files
+// produced are listed in the DownloadResult.
+func ArchiveBundle(opts DownloadOptions) DownloadOptions {
+	opts.WriteInfoJSON = true
+	opts.WriteDescription = true
+	opts.WriteThumbnail = true
+	opts.WriteSubs = true
+	opts.EmbedMetadata = true
+	return opts
+}
+
+// DownloadResult describes the outcome of a download.
+type DownloadResult struct {
+	// Files lists the files yt-dlp created that still exist afterwards: the final media
+	// files first, followed by sidecar files such as subtitles or thumbnails.
+	Files []string
+	// SkippedFragments counts fragments that were unavailable and left out.
+	SkippedFragments int
+	// Bytes is the total size of the final media files.
+	Bytes int64
+	// Elapsed is the time the whole invocation took.
+	Elapsed time.Duration
+	// Resumed reports whether yt-dlp continued a partial download.
+	Resumed bool
+	// Warnings lists options that were passed on but cannot take full effect, such as
+	// embedding subtitles in a container that does not support them.
+	Warnings []string
+}
+
+func (inst YTDLPInstance) ExecuteWithOptions(url string, opts DownloadOptions) (DownloadResult, error) {
+	return inst.ExecuteWithOptionsContext(context.Background(), url, opts)
+}
+
+func (inst YTDLPInstance) ExecuteWithOptionsContext(ctx context.Context, url string, opts DownloadOptions) (DownloadResult, error) {
+	if err := inst.checkURL(url); err != nil {
+		return DownloadResult{}, err
+	}
+	args, err := inst.optionArgs(opts)
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	filesOut, err := os.CreateTemp("", "ytdlp-files-")
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	filesOut.Close()
+	defer os.Remove(filesOut.Name())
+	started := inst.clock.Now()
+	args = append([]string{url, "--newline", "--print-to-file", "after_move:filepath", filesOut.Name()}, args...)
+	cmd := inst.command(ctx, args...)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	wait, err := inst.start(cmd)
+	if err != nil {
+		pw.Close()
+		return DownloadResult{}, err
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		err := wait()
+		pw.Close()
+		waitCh <- err
+	}()
+
+	var out strings.Builder
+	var itemErr *ItemError
+	var skipped int
+	var resumed bool
+	artifacts := make([]string, 0)
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		out.WriteString(line + "\n")
+		if p, ok := ParseProgressLine(line); ok && opts.OnProgress != nil {
+			opts.OnProgress(p)
+		} else if strings.HasPrefix(line, skipFragmentPrefix) {
+			skipped++
+		} else if strings.HasPrefix(line, resumePrefix) {
+			resumed = true
+		} else if m := itemErrorRegexp.FindStringSubmatch(line); m != nil {
+			itemErr = &ItemError{Extractor: m[1], ID: m[2], Message: m[3]}
+		} else if path := artifactPath(line); path != "" {
+			artifacts = append(artifacts, path)
+			if opts.OnDestination != nil && strings.HasPrefix(line, destinationPrefix) {
+				opts.OnDestination(path)
+			}
+		}
+	}
+	_, _ = io.Copy(io.Discard, pr)
+	if err := <-waitCh; err != nil {
+		if itemErr != nil {
+			return DownloadResult{}, itemErr
+		}
+		return DownloadResult{}, errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + out.String())
+	}
+	final, err := os.ReadFile(filesOut.Name())
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	media := existingFiles(strings.Split(string(final), "\n"))
+	var size int64
+	for _, f := range media {
+		if stat, err := os.Stat(f); err == nil {
+			size += stat.Size()
+		}
+	}
+	return DownloadResult{
+		Files:            existingFiles(append(media, artifacts...)),
+		SkippedFragments: skipped,
+		Bytes:            size,
+		Elapsed:          inst.clock.Now().Sub(started),
+		Resumed:          resumed,
+		Warnings:         opts.warnings(),
+	}, nil
+}
+
+// Download downloads url to outPath, which may be an output template, and blocks until
+// yt-dlp is done. An empty outPath uses yt-dlp's default output template.
+func (inst YTDLPInstance) Download(ctx context.Context, url, outPath string, opts DownloadOptions) (DownloadResult, error) {
+	if outPath != "" {
+		opts.ExtraArgs = append([]string{"-o", outPath}, opts.ExtraArgs...)
+	}
+	return inst.ExecuteWithOptionsContext(ctx, url, opts)
+}
+
+// DownloadFromInfoJSON downloads using metadata previously saved with --write-info-json,
+// skipping extraction. An empty outputPath uses yt-dlp's default output template.
+func (inst YTDLPInstance) DownloadFromInfoJSON(infoPath, outputPath string, args []string) error {
+	f, err := os.Open(infoPath)
+	if err != nil {
+		return fmt.Errorf("invalid info json: %v", err)
+	}
+	f.Close()
+	infoPath, err = filepath.Abs(infoPath)
+	if err != nil {
+		return fmt.Errorf("invalid info json: %v", err)
+	}
+	args = append([]string{"--load-info-json", infoPath}, args...)
+	if outputPath != "" {
+		args = append(args, "-o", outputPath)
+	}
+	cmd := inst.command(context.Background(), args...)
+	out, err := inst.combinedOutput(cmd)
+	if err != nil {
+		return errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + string(out))
+	}
+	return nil
+}
+
+// artifactPath returns the file named in a yt-dlp line announcing a written file.
+func artifactPath(line string) string {
+	m := artifactRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	for _, path := range m[1:] {
+		if path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// existingFiles deduplicates paths and drops those that no longer exist, such as
+// intermediate files removed after merging.
+func existingFiles(paths []string) []string {
+	files := make([]string, 0)
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" || slices.Contains(files, p) {
+			continue
+		}
+		if stat, err := os.Stat(p); err == nil && !stat.IsDir() {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// ItemError identifies the playlist or batch item that made yt-dlp fail. When several
+// items failed it describes the last one.
+type ItemError struct {
+	Extractor string
+	ID        string
+	Message   string
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("yt-dlp error: [%s] %s: %s", e.Extractor, e.ID, e.Message)
+}
+
+func (inst YTDLPInstance) optionArgs(opts DownloadOptions) ([]string, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	args := make([]string, 0)
+	if opts.RecodeVideo != "" {
+		if !slices.Contains(videoTargets, opts.RecodeVideo) {
+			return nil, fmt.Errorf("unsupported recode target: %s", opts.RecodeVideo)
+		}
+		if !inst.HasFFmpeg() {
+			return nil, errors.New("recoding video requires ffmpeg, but it was not found")
+		}
+		args = append(args, "--recode-video", opts.RecodeVideo)
+	}
+	if opts.RemuxVideo != "" {
+		if !slices.Contains(remuxTargets, opts.RemuxVideo) {
+			return nil, fmt.Errorf("unsupported remux target: %s", opts.RemuxVideo)
+		}
+		if !inst.HasFFmpeg() {
+			return nil, errors.New("remuxing video requires ffmpeg, but it was not found")
+		}
+		args = append(args, "--remux-video", opts.RemuxVideo)
+	}
+	if opts.ExtractAudio != "" {
+		if _, ok := audioEncoders[opts.ExtractAudio]; !ok {
+			return nil, fmt.Errorf("unsupported audio format: %s", opts.ExtractAudio)
+		}
+		if !inst.HasFFmpeg() {
+			return nil, errors.New("extracting audio requires ffmpeg, but it was not found")
+		}
+		args = append(args, "-x", "--audio-format", string(opts.ExtractAudio))
+	}
+	if opts.PlaylistItems != "" {
+		if err := ParsePlaylistItems(opts.PlaylistItems); err != nil {
+			return nil, err
+		}
+		args = append(args, "--playlist-items", opts.PlaylistItems)
+	}
+	dateAfter, err := dateArg(opts.DateAfter, opts.DateAfterSpec)
+	if err != nil {
+		return nil, err
+	}
+	if dateAfter != "" {
+		args = append(args, "--dateafter", dateAfter)
+	}
+	dateBefore, err := dateArg(opts.DateBefore, opts.DateBeforeSpec)
+	if err != nil {
+		return nil, err
+	}
+	if dateBefore != "" {
+		args = append(args, "--datebefore", dateBefore)
+	}
+	sleepArgs, err := sleepArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, sleepArgs...)
+	flags := []struct {
+		set  bool
+		flag string
+	}{
+		{opts.AbortOnError, "--abort-on-error"},
+		{opts.NoPart, "--no-part"},
+		{opts.NoContinue, "--no-continue"},
+		{opts.NoMtime, "--no-mtime"},
+		{opts.ForceOverwrites, "--force-overwrites"},
+		{opts.NoOverwrites, "--no-overwrites"},
+		{opts.WriteInfoJSON, "--write-info-json"},
+		{opts.WriteDescription, "--write-description"},
+		{opts.WriteThumbnail, "--write-thumbnail"},
+		{opts.WriteSubs, "--write-subs"},
+		{opts.EmbedMetadata, "--embed-metadata"},
+		{opts.EmbedSubs, "--embed-subs"},
+		{opts.SkipUnavailableFragments, "--skip-unavailable-fragments"},
+		{opts.AbortOnUnavailableFragment, "--abort-on-unavailable-fragments"},
+		{opts.PlaylistReverse, "--playlist-reverse"},
+		{opts.PlaylistRandom, "--playlist-random"},
+		{opts.WriteLink, "--write-link"},
+		{opts.WriteURLLink, "--write-url-link"},
+		{opts.WriteWeblocLink, "--write-webloc-link"},
+		{opts.WriteDesktopLink, "--write-desktop-link"},
+		{opts.Quiet, "--quiet"},
+		{opts.Verbose, "--verbose"},
+		{opts.ForceIPv4, "--force-ipv4"},
+		{opts.ForceIPv6, "--force-ipv6"},
+	}
+	for _, f := range flags {
+		if f.set {
+			args = append(args, f.flag)
+		}
+	}
+	if opts.NoMerge {
+		// replaces the instance's height selector, so its bounds are applied here
+		args = append(args, "-f", "bv"+inst.heightFilter()+",ba")
+	}
+	return append(args, opts.ExtraArgs...), nil
+}
+
+// warnings lists the options yt-dlp accepts but will not fully honour.
+func (opts DownloadOptions) warnings() []string {
+	var warnings []string
+	if opts.EmbedSubs {
+		for _, target := range []string{opts.RecodeVideo, opts.RemuxVideo} {
+			if target != "" && !slices.Contains(embedSubsContainers, target) {
+				warnings = append(warnings, fmt.Sprintf("subtitles cannot be embedded in %s", target))
+			}
+		}
+	}
+	return warnings
+}
+
+// validate reports all mutually exclusive options that are set together, so a
+// configuration can be fixed in one go.
+func (opts DownloadOptions) validate() error {
+	conflicts := []struct {
+		a, b bool
+		what string
+	}{
+		{opts.RemuxVideo != "", opts.RecodeVideo != "", "remux video and recode video"},
+		{opts.ExtractAudio != "", opts.RecodeVideo != "", "extract audio and recode video"},
+		{opts.ExtractAudio != "", opts.RemuxVideo != "", "extract audio and remux video"},
+		{opts.PlaylistReverse, opts.PlaylistRandom, "playlist reverse and playlist random"},
+		{opts.ForceOverwrites, opts.NoOverwrites, "force overwrites and no overwrites"},
+		{opts.SkipUnavailableFragments, opts.AbortOnUnavailableFragment, "skip and abort on unavailable fragments"},
+		{opts.Quiet, opts.Verbose, "quiet and verbose"},
+		{opts.ForceIPv4, opts.ForceIPv6, "force ipv4 and force ipv6"},
+	}
+	var errs []error
+	for _, c := range conflicts {
+		if c.a && c.b {
+			errs = append(errs, errors.New(c.what+" are mutually exclusive"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sleepArgs(opts DownloadOptions) ([]string, error) {
+	if opts.SleepInterval < 0 || opts.MaxSleepInterval < 0 || opts.SleepRequests < 0 {
+		return nil, errors.New("sleep intervals must not be negative")
+	}
+	args := make([]string, 0)
+	if opts.MaxSleepInterval > 0 {
+		if opts.SleepInterval == 0 {
+			return nil, errors.New("max sleep interval requires a sleep interval")
+		}
+		if opts.MaxSleepInterval < opts.SleepInterval {
+			return nil, errors.New("max sleep interval is less than sleep interval")
+		}
+	}
+	if opts.SleepInterval > 0 {
+		args = append(args, "--sleep-interval", formatSeconds(opts.SleepInterval))
+	}
+	if opts.MaxSleepInterval > 0 {
+		args = append(args, "--max-sleep-interval", formatSeconds(opts.MaxSleepInterval))
+	}
+	if opts.SleepRequests > 0 {
+		args = append(args, "--sleep-requests", formatSeconds(opts.SleepRequests))
+	}
+	return args, nil
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+func dateArg(t time.Time, spec string) (string, error) {
+	if !t.IsZero() && spec != "" {
+		return "", errors.New("date and date spec are mutually exclusive")
+	}
+	if !t.IsZero() {
+		return t.Format(dateLayout), nil
+	}
+	if spec != "" && !dateSpecRegexp.MatchString(spec) {
+		return "", fmt.Errorf("invalid date spec: %s", spec)
+	}
+	return spec, nil
+}
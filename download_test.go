@@ -0,0 +1,86 @@
+package ytdlp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDownloadOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DownloadOptions
+		want []string
+	}{
+		{"none", DownloadOptions{RemuxVideo: "mp4", Quiet: true, ForceIPv4: true}, nil},
+		{"remux and recode", DownloadOptions{RemuxVideo: "mkv", RecodeVideo: "mp4"}, []string{"remux video and recode video"}},
+		{"extract audio and recode", DownloadOptions{ExtractAudio: AudioMP3, RecodeVideo: "mp4"}, []string{"extract audio and recode video"}},
+		{"extract audio and remux", DownloadOptions{ExtractAudio: AudioMP3, RemuxVideo: "mkv"}, []string{"extract audio and remux video"}},
+		{"playlist order", DownloadOptions{PlaylistReverse: true, PlaylistRandom: true}, []string{"playlist reverse and playlist random"}},
+		{"overwrites", DownloadOptions{ForceOverwrites: true, NoOverwrites: true}, []string{"force overwrites and no overwrites"}},
+		{"fragments", DownloadOptions{SkipUnavailableFragments: true, AbortOnUnavailableFragment: true}, []string{"skip and abort on unavailable fragments"}},
+		{"verbosity", DownloadOptions{Quiet: true, Verbose: true}, []string{"quiet and verbose"}},
+		{"ip version", DownloadOptions{ForceIPv4: true, ForceIPv6: true}, []string{"force ipv4 and force ipv6"}},
+		{
+			name: "several conflicts",
+			opts: DownloadOptions{Quiet: true, Verbose: true, ForceIPv4: true, ForceIPv6: true, PlaylistReverse: true, PlaylistRandom: true},
+			want: []string{"playlist reverse and playlist random", "quiet and verbose", "force ipv4 and force ipv6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validate() = nil, want an error")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("validate() reported %d conflicts, want %d: %v", len(lines), len(tt.want), err)
+			}
+			for i, what := range tt.want {
+				if lines[i] != what+" are mutually exclusive" {
+					t.Errorf("conflict %d = %q, want %q", i, lines[i], what+" are mutually exclusive")
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadOptionsWarnings(t *testing.T) {
+	tests := []struct {
+		opts DownloadOptions
+		want []string
+	}{
+		{DownloadOptions{EmbedSubs: true}, nil},
+		{DownloadOptions{EmbedSubs: true, RemuxVideo: "mkv"}, nil},
+		{DownloadOptions{RemuxVideo: "avi"}, nil},
+		{DownloadOptions{EmbedSubs: true, RemuxVideo: "avi"}, []string{"subtitles cannot be embedded in avi"}},
+		{DownloadOptions{EmbedSubs: true, RecodeVideo: "flv"}, []string{"subtitles cannot be embedded in flv"}},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.warnings(); !slices.Equal(got, tt.want) {
+			t.Errorf("warnings() for %+v = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestNoMergeKeepsHeightBounds(t *testing.T) {
+	inst := YTDLPInstance{minHeight: 360, maxHeight: 720}
+	args, err := inst.optionArgs(DownloadOptions{NoMerge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.Index(args, "-f")
+	if i < 0 || i+1 == len(args) {
+		t.Fatalf("no format selector in %q", args)
+	}
+	if want := "bv[height>=360][height<=720],ba"; args[i+1] != want {
+		t.Errorf("format selector = %q, want %q", args[i+1], want)
+	}
+}
@@ -0,0 +1,137 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const ffmpegName = "ffmpeg"
+
+// FFmpegVersion reports the version of the ffmpeg binary at location, which may be
+// the binary itself or its containing directory. An empty location searches PATH.
+func FFmpegVersion(location string) (string, error) {
+	p, err := findFFmpeg(location)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(p, "-version").Output()
+	if err != nil {
+		return "", errors.New("ffmpeg error: " + err.Error())
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", errors.New("unexpected ffmpeg version output: " + line)
+	}
+	return fields[2], nil
+}
+
+func findFFmpeg(location string) (string, error) {
+	if location == "" {
+		return exec.LookPath(ffmpegName)
+	}
+	stat, err := os.Stat(location)
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return exec.LookPath(filepath.Join(location, ffmpegName))
+	}
+	return location, nil
+}
+
+// HasFFmpeg reports whether the ffmpeg yt-dlp will use is available: the one set with
+// WithFFmpegLocation, or otherwise the one on PATH.
+func (inst YTDLPInstance) HasFFmpeg() bool {
+	_, err := findFFmpeg(inst.ffmpegLocation)
+	return err == nil
+}
+
+// GetPreviewFrame returns a JPEG of the video frame at the given time. It downloads a
+// short clip around that time with --download-sections and extracts the first frame
+// with ffmpeg, so ffmpeg is required both by yt-dlp and the library. Cuts are made at
+// keyframes, so the frame may be slightly earlier than requested.
+func (inst YTDLPInstance) GetPreviewFrame(url string, at time.Duration) ([]byte, error) {
+	if at < 0 {
+		return nil, errors.New("negative timestamp")
+	}
+	ffmpeg, err := findFFmpeg(inst.ffmpegLocation)
+	if err != nil {
+		return nil, errors.New("preview frames require ffmpeg, but it was not found")
+	}
+	dir, err := os.MkdirTemp("", "ytdlp-preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	section := fmt.Sprintf("*%s-%s", formatSeconds(at), formatSeconds(at+2*time.Second))
+	err = inst.Execute(url, "--no-playlist", "-f", "bv/b", "--download-sections", section,
+		"-P", dir, "-o", "clip.%(ext)s")
+	if err != nil {
+		return nil, err
+	}
+	clips, err := filepath.Glob(filepath.Join(dir, "clip.*"))
+	if err != nil || len(clips) == 0 {
+		return nil, errors.New("no clip was downloaded")
+	}
+	out, err := exec.Command(ffmpeg, "-v", "error", "-i", clips[0], "-frames:v", "1",
+		"-f", "image2", "-c:v", "mjpeg", "pipe:1").Output()
+	if err != nil {
+		return nil, errors.New("ffmpeg error: " + err.Error())
+	}
+	return out, nil
+}
+
+// pipeFFmpeg starts cmd through the instance with r, a yt-dlp output stream, as its
+// stdin. The returned wait closes r once ffmpeg has exited so the yt-dlp process can
+// finish too, and reports ffmpeg's error or else the error reading r.
+func (inst YTDLPInstance) pipeFFmpeg(cmd *exec.Cmd, r *io.PipeReader) (wait func() error, err error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	ffmpegWait, err := inst.start(cmd)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	readErr := make(chan error, 1)
+	go func() {
+		var err error
+		buf := make([]byte, 32<<10)
+		for {
+			var n int
+			n, err = r.Read(buf)
+			if n > 0 {
+				if _, wErr := stdin.Write(buf[:n]); wErr != nil {
+					// ffmpeg stopped reading; its own exit status tells why
+					err = nil
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		stdin.Close()
+		if err == io.EOF || err == io.ErrClosedPipe {
+			err = nil
+		}
+		readErr <- err
+	}()
+	return func() error {
+		err := ffmpegWait()
+		r.Close()
+		if rErr := <-readErr; err == nil {
+			err = rErr
+		}
+		return err
+	}, nil
+}
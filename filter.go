@@ -0,0 +1,97 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type FilterOp string
+
+const (
+	FilterEq       FilterOp = "="
+	FilterNe       FilterOp = "!="
+	FilterLt       FilterOp = "<"
+	FilterLe       FilterOp = "<="
+	FilterGt       FilterOp = ">"
+	FilterGe       FilterOp = ">="
+	FilterPrefix   FilterOp = "^="
+	FilterSuffix   FilterOp = "$="
+	FilterContains FilterOp = "*="
+	FilterRegex    FilterOp = "~="
+)
+
+var filterCondRegexp = regexp.MustCompile(`^!?[\w.]+\s*(?:(?:!?(?:[<>]=?|[~^$*]?=)|!=)\??\s*\S.*)?$`)
+
+// MatchFilter composes conditions for --match-filters. All conditions must hold.
+type MatchFilter struct {
+	conds []string
+}
+
+func NewMatchFilter() *MatchFilter {
+	return &MatchFilter{}
+}
+
+func (f *MatchFilter) Number(field string, op FilterOp, value float64) *MatchFilter {
+	f.conds = append(f.conds, field+" "+string(op)+" "+strconv.FormatFloat(value, 'f', -1, 64))
+	return f
+}
+
+// Text adds a string comparison. Quotes and "&" in value are escaped; yt-dlp keeps
+// other backslashes as they are.
+func (f *MatchFilter) Text(field string, op FilterOp, value string) *MatchFilter {
+	quoted := "'" + strings.NewReplacer(`'`, `\'`, "&", `\&`).Replace(value) + "'"
+	f.conds = append(f.conds, field+" "+string(op)+" "+quoted)
+	return f
+}
+
+func (f *MatchFilter) Present(field string) *MatchFilter {
+	f.conds = append(f.conds, field)
+	return f
+}
+
+func (f *MatchFilter) Absent(field string) *MatchFilter {
+	f.conds = append(f.conds, "!"+field)
+	return f
+}
+
+func (f *MatchFilter) Build() string {
+	return strings.Join(f.conds, " & ")
+}
+
+func validateMatchFilter(expr string) error {
+	conds, err := splitFilterConds(expr)
+	if err != nil {
+		return err
+	}
+	for _, c := range conds {
+		if !filterCondRegexp.MatchString(c) {
+			return fmt.Errorf("invalid match filter condition: %q", c)
+		}
+	}
+	return nil
+}
+
+// splitFilterConds splits expr like yt-dlp does: at every "&" not preceded by a
+// backslash, regardless of quotes, with "\&" standing for a literal "&".
+func splitFilterConds(expr string) ([]string, error) {
+	conds := make([]string, 0)
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		if expr[i] == '&' && (i == 0 || expr[i-1] != '\\') {
+			conds = append(conds, expr[start:i])
+			start = i + 1
+		}
+	}
+	conds = append(conds, expr[start:])
+	for i, c := range conds {
+		c = strings.TrimSpace(strings.ReplaceAll(c, `\&`, "&"))
+		if c == "" {
+			return nil, errors.New("empty condition in match filter")
+		}
+		conds[i] = c
+	}
+	return conds, nil
+}
@@ -0,0 +1,56 @@
+package ytdlp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitFilterConds(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"duration > 60", []string{"duration > 60"}},
+		{"duration > 60 & !is_live", []string{"duration > 60", "!is_live"}},
+		{`title *= 'Tom \& Jerry'`, []string{"title *= 'Tom & Jerry'"}},
+		// quotes don't protect "&", like in yt-dlp
+		{"title *= 'a & b'", []string{"title *= 'a", "b'"}},
+	}
+	for _, tt := range tests {
+		got, err := splitFilterConds(tt.expr)
+		if err != nil {
+			t.Errorf("splitFilterConds(%q): %v", tt.expr, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitFilterConds(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+	if _, err := splitFilterConds("a & & b"); err == nil {
+		t.Error("empty condition accepted")
+	}
+}
+
+func TestMatchFilterTextEscapesAmpersand(t *testing.T) {
+	expr := NewMatchFilter().Text("title", FilterContains, "Tom & Jerry").Number("duration", FilterLt, 600).Build()
+	if want := `title *= 'Tom \& Jerry' & duration < 600`; expr != want {
+		t.Fatalf("Build() = %q, want %q", expr, want)
+	}
+	if err := validateMatchFilter(expr); err != nil {
+		t.Fatal(err)
+	}
+	conds, _ := splitFilterConds(expr)
+	if len(conds) != 2 {
+		t.Fatalf("got %d conditions, want 2", len(conds))
+	}
+}
+
+func TestMatchFilterTextKeepsBackslashes(t *testing.T) {
+	expr := NewMatchFilter().Text("path", FilterEq, `C:\x`).Build()
+	if want := `path = 'C:\x'`; expr != want {
+		t.Fatalf("Build() = %q, want %q", expr, want)
+	}
+	if err := validateMatchFilter(expr); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,146 @@
+package ytdlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Format struct {
+	FormatID       string  `json:"format_id"`
+	FormatNote     string  `json:"format_note"`
+	Ext            string  `json:"ext"`
+	Protocol       string  `json:"protocol"`
+	URL            string  `json:"url"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	FPS            float64 `json:"fps"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+	TBR            float64 `json:"tbr"`
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+	// RequestedFormats lists the parts of a merged format such as "bv+ba".
+	RequestedFormats []Format `json:"requested_formats"`
+	// Rows, Columns and Fragments describe storyboards: each fragment is a sprite sheet
+	// of Rows x Columns thumbnails covering Duration seconds of the video.
+	Rows      int        `json:"rows"`
+	Columns   int        `json:"columns"`
+	Fragments []Fragment `json:"fragments"`
+}
+
+type Fragment struct {
+	URL      string  `json:"url"`
+	Duration float64 `json:"duration"`
+}
+
+// GetBestFormats returns the best video-only and best audio-only formats as selected by yt-dlp.
+func (inst YTDLPInstance) GetBestFormats(url string) (video Format, audio Format, err error) {
+	formats, err := inst.resolveFormats(url, "bv,ba")
+	if err != nil {
+		return Format{}, Format{}, err
+	}
+	if len(formats) != 2 {
+		return Format{}, Format{}, fmt.Errorf("expected 2 formats, got %d", len(formats))
+	}
+	return formats[0], formats[1], nil
+}
+
+// ResolveFormat returns the format yt-dlp would download for selector without
+// downloading it. For merged selectors the parts are in RequestedFormats.
+func (inst YTDLPInstance) ResolveFormat(url, selector string) (Format, error) {
+	if selector == "" {
+		return Format{}, errors.New("empty format selector")
+	}
+	formats, err := inst.resolveFormats(url, selector)
+	if err != nil {
+		return Format{}, err
+	}
+	if len(formats) != 1 {
+		return Format{}, fmt.Errorf("selector resolves to %d formats, expected 1", len(formats))
+	}
+	return formats[0], nil
+}
+
+// GetBestFormatID returns the ID of the format yt-dlp would download for selector, such
+// as "137+140" for merged formats. An empty selector uses yt-dlp's default selection.
+// It is cheaper than ResolveFormat when only the ID is needed.
+func (inst YTDLPInstance) GetBestFormatID(url, selector string) (string, error) {
+	if err := inst.checkURL(url); err != nil {
+		return "", err
+	}
+	args := []string{url, "--print", "format_id", "--no-playlist"}
+	if selector != "" {
+		args = append(args, "-f", selector)
+	}
+	out, err := inst.output(args...)
+	if err != nil {
+		return "", err
+	}
+	ids := strings.Fields(out)
+	if len(ids) != 1 {
+		return "", fmt.Errorf("selector resolves to %d formats, expected 1", len(ids))
+	}
+	return ids[0], nil
+}
+
+// GetStoryboards returns the storyboard formats of the video at url, the sprite sheets
+// players use for seek bar previews. Not every site provides them.
+func (inst YTDLPInstance) GetStoryboards(url string) ([]Format, error) {
+	var info struct {
+		Formats []Format `json:"formats"`
+	}
+	if err := inst.dumpJSON(url, &info, "--skip-download", "--no-playlist"); err != nil {
+		return nil, err
+	}
+	storyboards := make([]Format, 0)
+	for _, f := range info.Formats {
+		if f.FormatNote == "storyboard" {
+			storyboards = append(storyboards, f)
+		}
+	}
+	return storyboards, nil
+}
+
+// DownloadStoryboard downloads the storyboard with the given format ID, as returned by
+// GetStoryboards, into dir and returns the written files. yt-dlp stores the sprite
+// sheets in a single MHTML file; the individual images are also available from the
+// format's Fragments.
+func (inst YTDLPInstance) DownloadStoryboard(url, formatID, dir string) ([]string, error) {
+	if formatID == "" {
+		return nil, errors.New("empty format id")
+	}
+	res, err := inst.ExecuteWithOptions(url, DownloadOptions{
+		ExtraArgs: []string{"--no-playlist", "-f", formatID, "-P", dir},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Files, nil
+}
+
+// resolveFormats returns the formats yt-dlp selects for selector, one per
+// comma-separated entry.
+func (inst YTDLPInstance) resolveFormats(url, selector string) ([]Format, error) {
+	if err := inst.checkURL(url); err != nil {
+		return nil, err
+	}
+	out, err := inst.output(url, "-f", selector, "-j", "--no-playlist")
+	if err != nil {
+		return nil, err
+	}
+	formats := make([]Format, 0)
+	d := json.NewDecoder(strings.NewReader(out))
+	for {
+		var f Format
+		if err := d.Decode(&f); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.New("failed to decode format: " + err.Error())
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
@@ -0,0 +1,150 @@
+package ytdlp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type DownloadState int
+
+const (
+	DownloadRunning DownloadState = iota
+	DownloadCompleted
+	DownloadFailed
+	DownloadCancelled
+)
+
+// DownloadStatus is a snapshot of a download started with StartDownload.
+type DownloadStatus struct {
+	State DownloadState
+	// Progress is the last progress yt-dlp reported.
+	Progress DownloadProgress
+	// Destinations lists the output files yt-dlp has announced so far.
+	Destinations []string
+}
+
+// DownloadHandle controls a download running in the background.
+type DownloadHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	status    DownloadStatus
+	cancelled bool
+	noPart    bool
+	result    DownloadResult
+	err       error
+}
+
+// StartDownload starts downloading url like ExecuteWithOptionsContext and returns a
+// handle to follow or cancel it, e.g. from a download manager. The callbacks in opts
+// are still called.
+func (inst YTDLPInstance) StartDownload(ctx context.Context, url string, opts DownloadOptions) *DownloadHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &DownloadHandle{cancel: cancel, done: make(chan struct{}), noPart: opts.NoPart}
+	onProgress, onDestination := opts.OnProgress, opts.OnDestination
+	opts.OnProgress = func(p DownloadProgress) {
+		h.mu.Lock()
+		h.status.Progress = p
+		h.mu.Unlock()
+		if onProgress != nil {
+			onProgress(p)
+		}
+	}
+	opts.OnDestination = func(path string) {
+		h.mu.Lock()
+		h.status.Destinations = append(h.status.Destinations, path)
+		h.mu.Unlock()
+		if onDestination != nil {
+			onDestination(path)
+		}
+	}
+	go func() {
+		res, err := inst.ExecuteWithOptionsContext(ctx, url, opts)
+		h.mu.Lock()
+		h.result, h.err = res, err
+		switch {
+		// a download that finished before Cancel took effect is not cancelled
+		case h.cancelled && err != nil:
+			h.status.State = DownloadCancelled
+		case err != nil:
+			h.status.State = DownloadFailed
+		default:
+			h.status.State = DownloadCompleted
+		}
+		h.mu.Unlock()
+		cancel()
+		close(h.done)
+	}()
+	return h
+}
+
+func (h *DownloadHandle) Status() DownloadStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.status
+	s.Destinations = slices.Clone(s.Destinations)
+	return s
+}
+
+// Wait blocks until the download has finished and returns its result.
+func (h *DownloadHandle) Wait() (DownloadResult, error) {
+	<-h.done
+	return h.result, h.err
+}
+
+// Cancel stops the download, including processes yt-dlp has spawned, and blocks until
+// yt-dlp has exited. If removePartial is set, the partial files of the announced
+// destinations are deleted so the download cannot be resumed. Cancelling a finished
+// download has no effect.
+func (h *DownloadHandle) Cancel(removePartial bool) error {
+	h.mu.Lock()
+	if h.status.State == DownloadRunning {
+		h.cancelled = true
+	}
+	h.mu.Unlock()
+	h.cancel()
+	<-h.done
+	s := h.Status()
+	// only a download stopped by Cancel leaves partial files behind
+	if !removePartial || s.State != DownloadCancelled {
+		return nil
+	}
+	var errs []error
+	for _, dest := range s.Destinations {
+		errs = append(errs, removePartialFiles(dest, h.noPart))
+	}
+	return errors.Join(errs...)
+}
+
+// removePartialFiles deletes the .part, .part-Frag* and .ytdl files of dest, and dest
+// itself if it was written without a .part file.
+func removePartialFiles(dest string, noPart bool) error {
+	if noPart {
+		if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	dir, base := filepath.Split(dest)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), base+".part") || e.Name() == base+".ytdl" {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,233 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnknownSize is returned by EstimateSize when yt-dlp cannot determine the size,
+// which is common for HLS and DASH live formats.
+const UnknownSize int64 = -1
+
+func (inst YTDLPInstance) EstimateSize(url, formatSelector string) (int64, error) {
+	if err := inst.checkURL(url); err != nil {
+		return 0, err
+	}
+	args := []string{url, "-O", "%(filesize,filesize_approx)d"}
+	if formatSelector != "" {
+		args = append(args, "-f", formatSelector)
+	}
+	out, err := inst.output(args...)
+	if err != nil {
+		return 0, err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if line == "NA" || line == "" {
+		return UnknownSize, nil
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected filesize output: %s", line)
+	}
+	return size, nil
+}
+
+// Print returns the output of --print template, e.g. "%(uploader)s - %(title)s".
+// Playlists produce one line per entry.
+func (inst YTDLPInstance) Print(url string, template string) (string, error) {
+	if err := inst.checkURL(url); err != nil {
+		return "", err
+	}
+	out, err := inst.output(url, "--print", template)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// GetTitle returns the title of the video at url. For a playlist only the first entry
+// is extracted and its title is returned, not the playlist's own title.
+func (inst YTDLPInstance) GetTitle(url string) (string, error) {
+	if err := inst.checkURL(url); err != nil {
+		return "", err
+	}
+	out, err := inst.output(url, "-I", "1", "--print", "title")
+	if err != nil {
+		return "", err
+	}
+	title, _, _ := strings.Cut(out, "\n")
+	return title, nil
+}
+
+// PrintFields returns the values of the given fields keyed by field name. Fields that
+// resolve to NA are left out of the map. For playlists only the first entry is used.
+func (inst YTDLPInstance) PrintFields(url string, fields []string) (map[string]string, error) {
+	if err := inst.checkURL(url); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		// without --print, yt-dlp would download the video
+		return nil, errors.New("no fields to print")
+	}
+	args := []string{url}
+	for _, f := range fields {
+		if f == "" || strings.Contains(f, ")") {
+			return nil, fmt.Errorf("invalid field name: %q", f)
+		}
+		args = append(args, "--print", "%("+f+")j")
+	}
+	out, err := inst.output(args...)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) < len(fields) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(fields), len(lines))
+	}
+	values := make(map[string]string, len(fields))
+	for i, f := range fields {
+		var v any
+		if err := json.Unmarshal([]byte(lines[i]), &v); err != nil {
+			return nil, fmt.Errorf("failed to decode field %s: %v", f, err)
+		}
+		switch v := v.(type) {
+		case nil:
+		case string:
+			if v != "NA" {
+				values[f] = v
+			}
+		default:
+			values[f] = lines[i]
+		}
+	}
+	return values, nil
+}
+
+// GetPlaylistURLs lists the video URLs of a playlist without extracting each entry.
+// A single video URL yields a one-element slice.
+func (inst YTDLPInstance) GetPlaylistURLs(url string) ([]string, error) {
+	if err := inst.checkURL(url); err != nil {
+		return nil, err
+	}
+	out, err := inst.output(url, "--flat-playlist", "--print", "%(webpage_url,url)s")
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" && line != "NA" {
+			urls = append(urls, line)
+		}
+	}
+	return urls, nil
+}
+
+// GetVideoInfoFull returns the full metadata for the video at url without downloading it.
+func (inst YTDLPInstance) GetVideoInfoFull(url string) (*YTDLPVideoInfo, error) {
+	vi := new(YTDLPVideoInfo)
+	if err := inst.dumpJSON(url, vi, "--skip-download", "--no-playlist"); err != nil {
+		return nil, err
+	}
+	return vi, nil
+}
+
+// GetInfoInto decodes the -J metadata for url into v, which must be a pointer, so
+// callers can pick the fields they need with their own struct. For a playlist the
+// metadata is the playlist with its entries.
+func (inst YTDLPInstance) GetInfoInto(url string, v any) error {
+	if v == nil {
+		return errors.New("nil target")
+	}
+	return inst.dumpJSON(url, v, "--skip-download")
+}
+
+func (inst YTDLPInstance) GetChapters(url string) ([]Chapter, error) {
+	vi := new(YTDLPVideoInfo)
+	if err := inst.dumpJSON(url, vi, "--skip-download", "--no-playlist"); err != nil {
+		return nil, err
+	}
+	if vi.Chapters == nil {
+		return []Chapter{}, nil
+	}
+	return vi.Chapters, nil
+}
+
+func (inst YTDLPInstance) GetThumbnails(url string) ([]Thumbnail, error) {
+	vi := new(YTDLPVideoInfo)
+	if err := inst.dumpJSON(url, vi, "--skip-download", "--no-playlist"); err != nil {
+		return nil, err
+	}
+	if vi.Thumbnails == nil {
+		return []Thumbnail{}, nil
+	}
+	return vi.Thumbnails, nil
+}
+
+// DurationString formats the duration as "1:02:03" or "2:03". Live streams and videos of
+// unknown length report no duration and are formatted as "--:--".
+func (vi YTDLPVideoInfo) DurationString() string {
+	if vi.Duration == 0 {
+		return "--:--"
+	}
+	h, m, s := vi.Duration/3600, vi.Duration%3600/60, vi.Duration%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// DurationISO8601 formats the duration as an ISO 8601 duration such as "PT1H2M3S".
+// An unknown duration is formatted as "PT0S".
+func (vi YTDLPVideoInfo) DurationISO8601() string {
+	if vi.Duration == 0 {
+		return "PT0S"
+	}
+	h, m, s := vi.Duration/3600, vi.Duration%3600/60, vi.Duration%60
+	d := "PT"
+	if h > 0 {
+		d += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 {
+		d += fmt.Sprintf("%dM", m)
+	}
+	if s > 0 {
+		d += fmt.Sprintf("%dS", s)
+	}
+	return d
+}
+
+// dumpJSON decodes the -J output for url into v.
+func (inst YTDLPInstance) dumpJSON(url string, v any, args ...string) error {
+	if err := inst.checkURL(url); err != nil {
+		return err
+	}
+	out, err := inst.output(append([]string{url, "-J"}, args...)...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		return errors.New("failed to decode video info: " + err.Error())
+	}
+	return nil
+}
+
+// output runs yt-dlp and returns its stdout; stderr is only used for error reporting.
+func (inst YTDLPInstance) output(args ...string) (string, error) {
+	return inst.outputContext(context.Background(), args...)
+}
+
+func (inst YTDLPInstance) outputContext(ctx context.Context, args ...string) (string, error) {
+	cmd := inst.command(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := inst.run(cmd); err != nil {
+		return "", errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + stderr.String())
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,55 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// RecordLive records the live stream at url from its start into consecutive files of
+// segmentDuration each. outputTemplate is an ffmpeg segment pattern with a counter,
+// such as "rec-%03d.ts". The stream is piped from yt-dlp into ffmpeg, which must be
+// installed, and segments are cut at keyframes, so their length varies slightly.
+//
+// Recording runs until the stream ends or ctx is done. On cancellation yt-dlp is
+// stopped first so ffmpeg can finalize the current segment, and ctx.Err() is returned.
+func (inst YTDLPInstance) RecordLive(ctx context.Context, url, outputTemplate string, segmentDuration time.Duration) error {
+	if !strings.Contains(outputTemplate, "%") {
+		return errors.New("output template must contain a segment counter such as %03d")
+	}
+	if segmentDuration <= 0 {
+		return errors.New("segment duration must be positive")
+	}
+	ffmpeg, err := findFFmpeg(inst.ffmpegLocation)
+	if err != nil {
+		return errors.New("live recording requires ffmpeg, but it was not found")
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r, err := inst.stream(ctx, url, []string{"--live-from-start", "--no-playlist"})
+	if err != nil {
+		return err
+	}
+	// not cancelled with ctx: ffmpeg exits on its own once yt-dlp's output ends
+	cmd := exec.CommandContext(context.WithoutCancel(ctx), ffmpeg, "-v", "error", "-i", "pipe:0",
+		"-map", "0", "-c", "copy", "-f", "segment", "-segment_time", formatSeconds(segmentDuration),
+		"-reset_timestamps", "1", outputTemplate)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	wait, err := inst.pipeFFmpeg(cmd, r)
+	if err != nil {
+		return err
+	}
+	err = wait()
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	if err != nil {
+		return errors.New("ffmpeg error: " + err.Error() + " | " + stderr.String())
+	}
+	return nil
+}
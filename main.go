@@ -2,6 +2,10 @@ package ytdlp
 
 import (
 	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,44 +13,151 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 )
 
-const exeName = "yt-dlp"
+const defaultExeName = "yt-dlp"
+
+var exeName = defaultExeName
+
+const checksumsName = "SHA2-256SUMS"
+
+const defaultGracePeriod = 5 * time.Second
 
 var client = http.Client{Timeout: 5 * time.Second}
 
+// Base URLs of the GitHub API and release downloads. They can be pointed at a mirror
+// or a test server.
+var (
+	GithubAPIBaseURL = "https://api.github.com"
+	GithubBaseURL    = "https://github.com"
+)
+
+// GithubRepo is the owner/repo the GitHub functions fetch releases from, e.g. a fork.
+var GithubRepo = "yt-dlp/yt-dlp"
+
 type GHDownloadData struct {
-	TagName string `json:"tag_name"`
+	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
 }
 
 type YTDLPInstance struct {
-	bPath string
+	bPath          string
+	ffmpegLocation string
+	args           []string
+	gracePeriod    time.Duration
+	clock          Clock
+	observer       func(InvocationResult)
+	minHeight      int
+	maxHeight      int
+	maxCapture     int
+	maxAudioBytes  int64
+	cmdHook        func(*exec.Cmd)
+	pagesDir       string
+	validateURLs   bool
+	proxyPool      *ProxyPool
 }
 
 type YTDLPVideoInfo struct {
-	Id        string `json:"id"`
-	Title     string `json:"title"`
-	Thumbnail string `json:"thumbnail"`
-	Duration  uint   `json:"duration"`
+	Id          string      `json:"id"`
+	Title       string      `json:"title"`
+	Thumbnail   string      `json:"thumbnail"`
+	Duration    uint        `json:"duration"`
+	Uploader    string      `json:"uploader"`
+	UploadDate  string      `json:"upload_date"`
+	Description string      `json:"description"`
+	ViewCount   int64       `json:"view_count"`
+	WebpageURL  string      `json:"webpage_url"`
+	Chapters    []Chapter   `json:"chapters"`
+	Thumbnails  []Thumbnail `json:"thumbnails"`
+}
+
+type Thumbnail struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type Chapter struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Title     string  `json:"title"`
 }
 
-func NewInstance(binPath string) (*YTDLPInstance, error) {
+func NewInstance(binPath string, opts ...Option) (*YTDLPInstance, error) {
 	if binPath == "" {
 		return nil, errors.New("invalid binary path")
 	}
-	return &YTDLPInstance{bPath: binPath}, nil
+	inst := &YTDLPInstance{bPath: binPath, gracePeriod: defaultGracePeriod, clock: systemClock{}}
+	for _, opt := range opts {
+		if err := opt(inst); err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+// Clone returns a copy of inst with opts applied on top of its configuration, leaving
+// inst untouched.
+func (inst YTDLPInstance) Clone(opts ...Option) (*YTDLPInstance, error) {
+	c := inst
+	c.args = slices.Clone(inst.args)
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// command builds a yt-dlp invocation. When ctx is done the process is interrupted so
+// yt-dlp can clean up its partial files, and killed if it is still running after the
+// instance's grace period. yt-dlp runs in its own process group (a job object on
+// Windows) so children such as ffmpeg are interrupted and killed with it; this also
+// means a Ctrl-C in the terminal reaches it only through ctx.
+func (inst YTDLPInstance) command(ctx context.Context, args ...string) *exec.Cmd {
+	globalArgs := slices.Clone(inst.args)
+	if inst.proxyPool != nil {
+		globalArgs = append([]string{"--proxy", inst.proxyPool.Next()}, globalArgs...)
+	}
+	if inst.ffmpegLocation != "" {
+		globalArgs = append(globalArgs, "--ffmpeg-location", inst.ffmpegLocation)
+	}
+	if f := inst.heightSelector(); f != "" {
+		globalArgs = append(globalArgs, "-f", f)
+	}
+	if inst.pagesDir != "" {
+		// yt-dlp writes pages to its working directory, keep downloads where they would be
+		if wd, err := os.Getwd(); err == nil {
+			globalArgs = append([]string{"--paths", "home:" + wd}, globalArgs...)
+		}
+	}
+	cmd := exec.CommandContext(ctx, inst.bPath, append(globalArgs, args...)...)
+	if inst.pagesDir != "" {
+		if p, err := filepath.Abs(cmd.Path); err == nil {
+			cmd.Path = p
+		}
+		cmd.Dir = inst.pagesDir
+	}
+	return cmd
 }
 
 func (inst YTDLPInstance) Execute(url string, args ...string) error {
-	if url == "" {
-		return errors.New("empty url")
+	return inst.ExecuteContext(context.Background(), url, args...)
+}
+
+func (inst YTDLPInstance) ExecuteContext(ctx context.Context, url string, args ...string) error {
+	if err := inst.checkURL(url); err != nil {
+		return err
 	}
 	args = slices.Insert(args, 0, url)
-	cmd := exec.Command(inst.bPath, args...)
-	out, err := cmd.CombinedOutput()
+	cmd := inst.command(ctx, args...)
+	out, err := inst.combinedOutput(cmd)
 	if err != nil {
 		return errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + string(out))
 	}
@@ -54,38 +165,52 @@ func (inst YTDLPInstance) Execute(url string, args ...string) error {
 }
 
 func (inst YTDLPInstance) ExecuteStdout(url string, args ...string) (io.Reader, error) {
-	if url == "" {
-		return nil, errors.New("empty url")
+	if err := inst.checkURL(url); err != nil {
+		return nil, err
 	}
 	args = slices.Insert(args, 0, url)
-	cmd := exec.Command(inst.bPath, args...)
+	cmd := inst.command(context.Background(), args...)
 	pr, pw := io.Pipe()
 	cmd.Stdout = pw
 	cmd.Stderr = pw
-	if err := cmd.Start(); err != nil {
+	wait, err := inst.start(cmd)
+	if err != nil {
 		pw.Close()
 		return nil, err
 	}
 	go func() {
 		defer pw.Close()
-		cmd.Wait()
+		wait()
 	}()
 	return pr, nil
 }
 
 func (inst YTDLPInstance) DumpStdout(url string, args ...string) (string, error) {
-	if url == "" {
-		return "", errors.New("empty url")
+	if err := inst.checkURL(url); err != nil {
+		return "", err
 	}
-	cmd := exec.Command(inst.bPath, append(args, url)...)
-	out, err := cmd.CombinedOutput()
-	return string(out), err
+	cmd := inst.command(context.Background(), append(args, url)...)
+	if inst.maxCapture == 0 {
+		out, err := inst.combinedOutput(cmd)
+		return string(out), err
+	}
+	b := &cappedBuffer{max: inst.maxCapture}
+	cmd.Stdout = b
+	cmd.Stderr = b
+	err := inst.run(cmd)
+	out := b.buf.String()
+	if b.dropped > 0 {
+		out += fmt.Sprintf("\n[output truncated: %d bytes omitted]", b.dropped)
+	}
+	return out, err
 }
 
+// GetVideoInfo returns info for the first YouTube search result for query.
+// Use GetVideoInfoFull for a known URL.
 func (inst YTDLPInstance) GetVideoInfo(query string) (*YTDLPVideoInfo, error) {
-	args := append(make([]string, 0), "ytsearch:"+query, "-s", "-O", "%(.{id,title,thumbnail,duration})#j")
-	cmd := exec.Command(inst.bPath, args...)
-	out, err := cmd.CombinedOutput()
+	args := append(make([]string, 0), string(SearchYouTube)+":"+query, "-s", "-O", videoInfoTemplate)
+	cmd := inst.command(context.Background(), args...)
+	out, err := inst.combinedOutput(cmd)
 	if err != nil {
 		return nil, errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + string(out))
 	}
@@ -107,27 +232,30 @@ func decodeVideoInfo(stdout string) (*YTDLPVideoInfo, error) {
 }
 
 func (inst YTDLPInstance) ExecuteStream(url string, args []string) (io.Reader, error) {
-	args = slices.Insert(args, 0, url)
-	args = append(args, "-o", "-", "--newline")
-	cmd := exec.Command(inst.bPath, args...)
-
-	stdoutRd, stdoutW := io.Pipe()
-	stderrRd, stderrW := io.Pipe()
+	return inst.ExecuteStreamContext(context.Background(), url, args)
+}
 
-	cmd.Stdout = stdoutW
-	cmd.Stderr = stderrW
+func (inst YTDLPInstance) ExecuteStreamContext(ctx context.Context, url string, args []string) (io.Reader, error) {
+	r, err := inst.stream(ctx, url, args)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
 
-	if err := cmd.Start(); err != nil {
+// stream is ExecuteStreamContext returning the pipe itself, so callers that stop
+// reading early can close it and let the yt-dlp process finish.
+func (inst YTDLPInstance) stream(ctx context.Context, url string, args []string) (*io.PipeReader, error) {
+	args = append(slices.Clone(args), "-o", "-", "--newline")
+	stdoutRd, stderrRd, err := inst.streams(ctx, url, args)
+	if err != nil {
 		return nil, err
 	}
-	go func() {
-		defer stdoutW.Close()
-		cmd.Wait()
-	}()
 
 	// blocks return until yt-dlp has started downloading or has errored
 	ytErrCh := make(chan error)
 	go func() {
+		var ytErr error
 		stderrLineScanner := bufio.NewScanner(stderrRd)
 		for stderrLineScanner.Scan() {
 			const downloadPrefix = "[download]"
@@ -136,23 +264,107 @@ func (inst YTDLPInstance) ExecuteStream(url string, args []string) (io.Reader, e
 			if strings.HasPrefix(line, downloadPrefix) {
 				break
 			} else if strings.HasPrefix(line, errorPrefix) {
-				ytErrCh <- errors.New(line[len(errorPrefix):])
-				return
+				ytErr = errors.New(line[len(errorPrefix):])
+				break
 			}
 		}
-		ytErrCh <- nil
+		ytErrCh <- ytErr
 		_, _ = io.Copy(io.Discard, stderrRd)
 	}()
 	return stdoutRd, <-ytErrCh
 }
 
+// ExecuteStreams runs yt-dlp with url and args and returns its raw stdout and stderr
+// for custom parsing. Both readers must be drained or the process blocks. Once the
+// process exits, stderr returns EOF and stdout returns EOF or the process error.
+func (inst YTDLPInstance) ExecuteStreams(ctx context.Context, url string, args []string) (stdout, stderr io.Reader, err error) {
+	stdoutRd, stderrRd, err := inst.streams(ctx, url, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stdoutRd, stderrRd, nil
+}
+
+func (inst YTDLPInstance) streams(ctx context.Context, url string, args []string) (stdout, stderr *io.PipeReader, err error) {
+	if err := inst.checkURL(url); err != nil {
+		return nil, nil, err
+	}
+	args = slices.Insert(args, 0, url)
+	cmd := inst.command(ctx, args...)
+
+	stdoutRd, stdoutW := io.Pipe()
+	stderrRd, stderrW := io.Pipe()
+
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+
+	wait, err := inst.start(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	go func() {
+		err := wait()
+		stderrW.Close()
+		if err != nil {
+			err = errors.New("yt-dlp error: " + err.Error())
+		}
+		// readers of stdout see the process failure instead of a plain EOF
+		stdoutW.CloseWithError(err)
+	}()
+	return stdoutRd, stderrRd, nil
+}
+
+// StreamTee streams like ExecuteStreamContext while copying every byte read from the
+// returned reader to w, e.g. to cache a download while serving it. Errors from w and
+// from the yt-dlp process are returned by Read.
+func (inst YTDLPInstance) StreamTee(ctx context.Context, url string, args []string, w io.Writer) (io.Reader, error) {
+	r, err := inst.ExecuteStreamContext(ctx, url, args)
+	if err != nil {
+		return nil, err
+	}
+	return io.TeeReader(r, w), nil
+}
+
+// SetExeName changes the release asset fetched by the GitHub download functions,
+// e.g. "yt-dlp_linux" or "yt-dlp.exe". An empty name restores the default "yt-dlp".
+func SetExeName(name string) {
+	if name == "" {
+		name = defaultExeName
+	}
+	exeName = name
+}
+
+// SetGithubInsecureSkipVerify disables TLS certificate validation for the GitHub
+// download functions, e.g. behind an intercepting proxy. This is insecure and
+// independent of WithNoCheckCertificate.
+func SetGithubInsecureSkipVerify(skip bool) {
+	if !skip {
+		client.Transport = nil
+		return
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	client.Transport = t
+}
+
 func GetGithubReleases(page, entries int) ([]GHDownloadData, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/yt-dlp/yt-dlp/releases?page=%d&per_page=%d", page, entries)
-	r, err := client.Get(url)
+	return GetGithubReleasesContext(context.Background(), page, entries)
+}
+
+func GetGithubReleasesContext(ctx context.Context, page, entries int) ([]GHDownloadData, error) {
+	return githubReleases(ctx, GithubRepo, page, entries)
+}
+
+func githubReleases(ctx context.Context, repo string, page, entries int) ([]GHDownloadData, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases?page=%d&per_page=%d", GithubAPIBaseURL, repo, page, entries)
+	r, err := get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
+	if err := githubAPIError(r); err != nil {
+		return nil, err
+	}
 	var d []GHDownloadData
 	dErr := json.NewDecoder(r.Body).Decode(&d)
 	if dErr != nil {
@@ -161,47 +373,244 @@ func GetGithubReleases(page, entries int) ([]GHDownloadData, error) {
 	return d, nil
 }
 
+// GetReleaseNotes returns the release notes of version, in Markdown, and when it was
+// published.
+func GetReleaseNotes(version string) (string, time.Time, error) {
+	if version == "" {
+		return "", time.Time{}, errors.New("empty version")
+	}
+	url := fmt.Sprintf("%s/repos/%s/releases/tags/%s", GithubAPIBaseURL, GithubRepo, version)
+	r, err := get(context.Background(), url)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer r.Body.Close()
+	if err := githubAPIError(r); err != nil {
+		return "", time.Time{}, err
+	}
+	var d GHDownloadData
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		return "", time.Time{}, err
+	}
+	return d.Body, d.PublishedAt, nil
+}
+
+func githubAPIError(r *http.Response) error {
+	if r.StatusCode == http.StatusOK {
+		return nil
+	}
+	var e struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&e)
+	if r.Header.Get("X-RateLimit-Remaining") == "0" {
+		return fmt.Errorf("github api rate limit exceeded: %s", e.Message)
+	}
+	return fmt.Errorf("github api error (%s): %s", r.Status, e.Message)
+}
+
 func DownloadLatestFromGithub(path string) error {
-	r, err := GetGithubReleases(1, 1)
+	_, err := DownloadLatestFromGithubVersioned(path)
+	return err
+}
+
+// DownloadLatestFromGithubVersioned downloads the latest release and returns its tag.
+func DownloadLatestFromGithubVersioned(path string) (string, error) {
+	return DownloadLatestFromGithubContext(context.Background(), path)
+}
+
+// DownloadLatestFromGithubContext is like DownloadLatestFromGithubVersioned but aborts
+// the download when ctx is done.
+func DownloadLatestFromGithubContext(ctx context.Context, path string) (string, error) {
+	r, err := GetGithubReleasesContext(ctx, 1, 1)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if len(r) == 0 {
+		return "", errors.New("no releases found on GitHub")
 	}
 	v := r[0].TagName
-	err = DownloadFromGithub(path, v)
+	err = DownloadFromGithubContext(ctx, path, v, nil)
 	if err != nil {
-		return fmt.Errorf("failed to download bin from GitHub releases: %v", err)
+		return "", fmt.Errorf("failed to download bin from GitHub releases: %v", err)
 	}
-	return nil
+	return v, nil
 }
 
+// DownloadFromGithub downloads the given release to path. An interrupted download is
+// resumed from path+".part", and the result is verified against the release's
+// SHA2-256SUMS when the release provides one.
 func DownloadFromGithub(path, version string) error {
-	url := fmt.Sprintf("https://github.com/yt-dlp/yt-dlp/releases/download/%s/%s", version, exeName)
-	if err := downloadFile(path, url); err != nil {
+	return DownloadFromGithubWithProgress(path, version, nil)
+}
+
+// DownloadFromGithubWithProgress is like DownloadFromGithub but calls progress as the
+// binary is written. total is -1 if the server does not report the size.
+func DownloadFromGithubWithProgress(path, version string, progress func(done, total int64)) error {
+	return DownloadFromGithubContext(context.Background(), path, version, progress)
+}
+
+// DownloadFromGithubContext is like DownloadFromGithubWithProgress but aborts the
+// download when ctx is done. The partial file is kept so a later call can resume it.
+func DownloadFromGithubContext(ctx context.Context, path, version string, progress func(done, total int64)) error {
+	return downloadRelease(ctx, GithubRepo, path, version, exeName, progress)
+}
+
+func downloadRelease(ctx context.Context, repo, path, version, asset string, progress func(done, total int64)) error {
+	base := fmt.Sprintf("%s/%s/releases/download/%s/", GithubBaseURL, repo, version)
+	sum, err := releaseChecksum(ctx, base+checksumsName, asset)
+	if err != nil {
 		return err
 	}
-	err := setExecPermission(path)
-	return err
+	part := path + ".part"
+	resumed, err := downloadFile(ctx, part, base+asset, progress)
+	if err != nil {
+		return err
+	}
+	if sum != "" {
+		err = verifyChecksum(part, sum)
+		if err != nil && resumed {
+			// the partial file may be stale, so retry once from scratch
+			_ = os.Remove(longPath(part))
+			if _, err = downloadFile(ctx, part, base+asset, progress); err != nil {
+				return err
+			}
+			err = verifyChecksum(part, sum)
+		}
+		if err != nil {
+			_ = os.Remove(longPath(part))
+			return err
+		}
+	}
+	if err := os.Rename(longPath(part), longPath(path)); err != nil {
+		return err
+	}
+	return setExecPermission(path)
 }
 
-func downloadFile(path, url string) error {
-	out, err := os.Create(path)
+// downloadFile downloads url to path, resuming from the existing contents of path if
+// the server supports range requests. It reports whether the download was resumed.
+func downloadFile(ctx context.Context, path, url string, progress func(done, total int64)) (bool, error) {
+	var offset int64
+	if stat, err := os.Stat(longPath(path)); err == nil {
+		offset = stat.Size()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	flags := os.O_CREATE | os.O_WRONLY
+	resumed := false
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		resumed = true
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is already complete
+		return true, nil
+	default:
+		return false, fmt.Errorf("download failed: %s", res.Status)
+	}
+	out, err := os.OpenFile(longPath(path), flags, 0644)
+	if err != nil {
+		return false, err
 	}
 	defer out.Close()
-	res, err := client.Get(url)
+	var w io.Writer = out
+	if progress != nil {
+		pw := &progressWriter{w: out, total: -1, fn: progress}
+		if resumed {
+			pw.done = offset
+		}
+		if res.ContentLength >= 0 {
+			pw.total = pw.done + res.ContentLength
+		}
+		w = pw
+	}
+	_, err = io.Copy(w, res.Body)
 	if err != nil {
-		return err
+		return resumed, err
+	}
+	return resumed, nil
+}
+
+type progressWriter struct {
+	w     io.Writer
+	done  int64
+	total int64
+	fn    func(done, total int64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.done += int64(n)
+	pw.fn(pw.done, pw.total)
+	return n, err
+}
+
+// releaseChecksum looks up the SHA-256 of asset in a release's checksum file. It returns
+// an empty string if the release has no checksum file.
+func releaseChecksum(ctx context.Context, url, asset string) (string, error) {
+	res, err := get(ctx, url)
+	if err != nil {
+		return "", err
 	}
 	defer res.Body.Close()
-	_, err = io.Copy(out, res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksums: %s", res.Status)
+	}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum for %s", asset)
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+func verifyChecksum(path, sum string) error {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
 		return err
 	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", sum, got)
+	}
 	return nil
 }
 
 func setExecPermission(fpath string) error {
+	fpath = longPath(fpath)
 	stat, err := os.Stat(fpath)
 	if err != nil {
 		return err
@@ -0,0 +1,173 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		partial     string // existing contents of the file, if any
+		status      int
+		body        string
+		wantRange   string
+		want        string
+		wantResumed bool
+		wantErr     bool
+	}{
+		{name: "fresh", status: http.StatusOK, body: "hello world", want: "hello world"},
+		{name: "resumed", partial: "hello ", status: http.StatusPartialContent, body: "world",
+			wantRange: "bytes=6-", want: "hello world", wantResumed: true},
+		{name: "range ignored", partial: "stale data", status: http.StatusOK, body: "hello world",
+			wantRange: "bytes=10-", want: "hello world"},
+		{name: "already complete", partial: "hello world", status: http.StatusRequestedRangeNotSatisfiable,
+			wantRange: "bytes=11-", want: "hello world", wantResumed: true},
+		{name: "server error", partial: "hello ", status: http.StatusInternalServerError,
+			wantRange: "bytes=6-", want: "hello ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Range"); got != tt.wantRange {
+					t.Errorf("Range = %q, want %q", got, tt.wantRange)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			path := filepath.Join(t.TempDir(), "yt-dlp.part")
+			if tt.partial != "" {
+				if err := os.WriteFile(path, []byte(tt.partial), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resumed, err := downloadFile(context.Background(), path, srv.URL, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if resumed != tt.wantResumed {
+				t.Errorf("downloadFile() resumed = %v, want %v", resumed, tt.wantResumed)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("file contents = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseChecksum(t *testing.T) {
+	const sum = "0123456789abcdef"
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "listed", status: http.StatusOK, body: "ffff  yt-dlp.exe\n" + strings.ToUpper(sum) + "  yt-dlp\n", want: sum},
+		{name: "binary mode", status: http.StatusOK, body: sum + " *yt-dlp\n", want: sum},
+		{name: "no sums file", status: http.StatusNotFound, want: ""},
+		{name: "asset missing", status: http.StatusOK, body: "ffff  yt-dlp.exe\n", wantErr: true},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			got, err := releaseChecksum(context.Background(), srv.URL, "yt-dlp")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("releaseChecksum() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("releaseChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadRelease(t *testing.T) {
+	asset := []byte("#!/bin/sh\necho yt-dlp\n")
+	h := sha256.Sum256(asset)
+	sum := hex.EncodeToString(h[:])
+	tests := []struct {
+		name     string
+		partial  string
+		sums     string
+		wantGets int32
+		wantErr  bool
+	}{
+		{name: "fresh", sums: sum + "  yt-dlp\n", wantGets: 1},
+		{name: "no sums file", wantGets: 1},
+		// the stale partial file fails the checksum, so the asset is fetched again in full
+		{name: "stale resume", partial: "stale", sums: sum + "  yt-dlp\n", wantGets: 2},
+		{name: "mismatch", sums: strings.Repeat("0", 64) + "  yt-dlp\n", wantGets: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gets atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/yt-dlp/yt-dlp/releases/download/2024.01.01/" + checksumsName:
+					if tt.sums == "" {
+						http.NotFound(w, r)
+						return
+					}
+					_, _ = w.Write([]byte(tt.sums))
+				case "/yt-dlp/yt-dlp/releases/download/2024.01.01/yt-dlp":
+					gets.Add(1)
+					// handles Range, answering 206 or 416
+					http.ServeContent(w, r, "yt-dlp", time.Time{}, bytes.NewReader(asset))
+				default:
+					t.Errorf("unexpected request for %s", r.URL.Path)
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+			old := GithubBaseURL
+			GithubBaseURL = srv.URL
+			t.Cleanup(func() { GithubBaseURL = old })
+
+			path := filepath.Join(t.TempDir(), "yt-dlp")
+			if tt.partial != "" {
+				if err := os.WriteFile(path+".part", []byte(tt.partial), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := downloadRelease(context.Background(), "yt-dlp/yt-dlp", path, "2024.01.01", "yt-dlp", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadRelease() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := gets.Load(); got != tt.wantGets {
+				t.Errorf("asset fetched %d times, want %d", got, tt.wantGets)
+			}
+			if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+				t.Errorf("partial file left behind: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("binary written despite the failed download")
+				}
+				return
+			}
+			if !bytes.Equal(got, asset) {
+				t.Errorf("binary = %q, want %q", got, asset)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package ytdlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+const nightlyRepo = "yt-dlp/yt-dlp-nightly-builds"
+
+// platformAssets maps GOOS/GOARCH to the standalone release binary for that platform.
+var platformAssets = map[string]string{
+	"windows/amd64": "yt-dlp.exe",
+	"windows/386":   "yt-dlp_x86.exe",
+	"windows/arm64": "yt-dlp_arm64.exe",
+	"darwin/amd64":  "yt-dlp_macos",
+	"darwin/arm64":  "yt-dlp_macos",
+	"linux/amd64":   "yt-dlp_linux",
+	"linux/arm64":   "yt-dlp_linux_aarch64",
+	"linux/arm":     "yt-dlp_linux_armv7l",
+}
+
+// DownloadLatestNightly downloads the latest nightly build for the current platform to
+// path and returns its version. Nightly builds carry the newest extractor fixes. A name
+// set with SetExeName takes precedence over the platform's asset.
+func DownloadLatestNightly(path string) (string, error) {
+	ctx := context.Background()
+	r, err := githubReleases(ctx, nightlyRepo, 1, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(r) == 0 {
+		return "", errors.New("no nightly releases found on GitHub")
+	}
+	v := r[0].TagName
+	if err := downloadRelease(ctx, nightlyRepo, path, v, platformAsset(), nil); err != nil {
+		return "", fmt.Errorf("failed to download nightly bin from GitHub releases: %v", err)
+	}
+	return v, nil
+}
+
+// platformAsset returns the release asset for the current platform, falling back to the
+// zipimport binary, which needs Python.
+func platformAsset() string {
+	if exeName != defaultExeName {
+		return exeName
+	}
+	if a, ok := platformAssets[runtime.GOOS+"/"+runtime.GOARCH]; ok {
+		return a
+	}
+	return defaultExeName
+}
@@ -0,0 +1,100 @@
+package ytdlp
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// InvocationResult describes a finished yt-dlp invocation.
+type InvocationResult struct {
+	Args     []string // argv with sensitive values redacted
+	Start    time.Time
+	End      time.Time
+	ExitCode int // -1 if the process did not start or was killed by a signal
+	Err      error
+}
+
+// WithObserver registers fn to be called after every yt-dlp invocation, for example to
+// record metrics. ffmpeg processes reading yt-dlp's output directly are reported too.
+// fn is called synchronously and should not block.
+func WithObserver(fn func(InvocationResult)) Option {
+	return func(inst *YTDLPInstance) error {
+		if fn == nil {
+			return errors.New("nil observer")
+		}
+		inst.observer = fn
+		return nil
+	}
+}
+
+// start starts cmd and returns a function that waits for it. Every yt-dlp process, and
+// every ffmpeg process fed by one, is run through start so the command hook and the
+// observer see each invocation.
+func (inst YTDLPInstance) start(cmd *exec.Cmd) (wait func() error, err error) {
+	if inst.cmdHook != nil {
+		inst.cmdHook(cmd)
+	}
+	setupProcess(cmd)
+	// set by Cancel, which has returned by the time Wait does
+	var killTimer Timer
+	cmd.Cancel = func() error {
+		if err := interruptProcess(cmd.Process); err != nil {
+			return killProcess(cmd.Process)
+		}
+		killTimer = inst.clock.AfterFunc(inst.gracePeriod, func() {
+			_ = killProcess(cmd.Process)
+		})
+		return nil
+	}
+	started := inst.clock.Now()
+	if err := cmd.Start(); err != nil {
+		inst.observe(cmd, started, err)
+		return nil, err
+	}
+	attachProcess(cmd.Process)
+	return func() error {
+		err := cmd.Wait()
+		if killTimer != nil {
+			// the process group may be gone and its id reused
+			killTimer.Stop()
+		}
+		releaseProcess(cmd.Process)
+		inst.observe(cmd, started, err)
+		return err
+	}, nil
+}
+
+func (inst YTDLPInstance) run(cmd *exec.Cmd) error {
+	wait, err := inst.start(cmd)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+func (inst YTDLPInstance) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := inst.run(cmd)
+	return b.Bytes(), err
+}
+
+func (inst YTDLPInstance) observe(cmd *exec.Cmd, started time.Time, err error) {
+	if inst.observer == nil {
+		return
+	}
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	inst.observer(InvocationResult{
+		Args:     redactArgs(cmd.Args),
+		Start:    started,
+		End:      inst.clock.Now(),
+		ExitCode: code,
+		Err:      err,
+	})
+}
@@ -0,0 +1,630 @@
+package ytdlp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var outputTypes = []string{"subtitle", "thumbnail", "description", "infojson", "link", "pl_thumbnail", "pl_description", "pl_infojson", "chapter", "pl_video"}
+
+type Option func(*YTDLPInstance) error
+
+// WithGracePeriod sets how long a cancelled yt-dlp process may take to exit after being
+// interrupted before it is killed. The default is 5 seconds.
+func WithGracePeriod(d time.Duration) Option {
+	return func(inst *YTDLPInstance) error {
+		if d < 0 {
+			return errors.New("negative grace period")
+		}
+		inst.gracePeriod = d
+		return nil
+	}
+}
+
+func WithFFmpegLocation(path string) Option {
+	return func(inst *YTDLPInstance) error {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid ffmpeg location: %v", err)
+		}
+		// absolute, as yt-dlp may run in another directory, see WithWritePages
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("invalid ffmpeg location: %v", err)
+		}
+		inst.ffmpegLocation = abs
+		return nil
+	}
+}
+
+// WithMatchFilter only downloads videos matching expr, e.g. "view_count > 1000 & duration < 600".
+// It can be given multiple times, in which case a video matching any of the filters is downloaded.
+func WithMatchFilter(expr string) Option {
+	return func(inst *YTDLPInstance) error {
+		if err := validateMatchFilter(expr); err != nil {
+			return err
+		}
+		inst.args = append(inst.args, "--match-filters", expr)
+		return nil
+	}
+}
+
+// WithPaths routes files to different directories, keyed by "home", "temp" or an output
+// type such as "thumbnail" or "subtitle".
+func WithPaths(paths map[string]string) Option {
+	return func(inst *YTDLPInstance) error {
+		for _, t := range slices.Sorted(maps.Keys(paths)) {
+			if t != "home" && t != "temp" && !slices.Contains(outputTypes, t) {
+				return fmt.Errorf("invalid path type: %s", t)
+			}
+			inst.args = append(inst.args, "--paths", t+":"+paths[t])
+		}
+		return nil
+	}
+}
+
+// WithBufferSize sets the download buffer size in bytes. Like WithHTTPChunkSize it is a
+// performance knob without a universally best value; measure before changing it.
+func WithBufferSize(bytes int) Option {
+	return func(inst *YTDLPInstance) error {
+		if bytes <= 0 {
+			return errors.New("buffer size must be positive")
+		}
+		inst.args = append(inst.args, "--buffer-size", strconv.Itoa(bytes))
+		return nil
+	}
+}
+
+// WithHTTPChunkSize downloads HTTP formats in chunks of the given size in bytes, which
+// can help against throttling by some servers.
+func WithHTTPChunkSize(bytes int) Option {
+	return func(inst *YTDLPInstance) error {
+		if bytes <= 0 {
+			return errors.New("http chunk size must be positive")
+		}
+		inst.args = append(inst.args, "--http-chunk-size", strconv.Itoa(bytes))
+		return nil
+	}
+}
+
+// WithNoCheckCertificate disables TLS certificate validation in yt-dlp. This is insecure
+// and should only be used for trusted sources with self-signed certificates. It does not
+// affect the GitHub download functions, see SetGithubInsecureSkipVerify.
+func WithNoCheckCertificate() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.args = append(inst.args, "--no-check-certificates")
+		return nil
+	}
+}
+
+// WithAgeLimit skips videos not suitable for the given age. Skipped videos are not
+// recorded in a download archive, so they are checked again on every run.
+func WithAgeLimit(years int) Option {
+	return func(inst *YTDLPInstance) error {
+		if years < 0 {
+			return errors.New("age limit must not be negative")
+		}
+		inst.args = append(inst.args, "--age-limit", strconv.Itoa(years))
+		return nil
+	}
+}
+
+// WithClientCertificate authenticates with a client certificate and its private key in
+// separate PEM files. password decrypts the key and may be empty; it is redacted
+// wherever the library reports arguments.
+func WithClientCertificate(certPath, keyPath, password string) Option {
+	return func(inst *YTDLPInstance) error {
+		if certPath == "" || keyPath == "" {
+			return errors.New("client certificate and key must both be provided")
+		}
+		paths := []string{certPath, keyPath}
+		for i, p := range paths {
+			if _, err := os.Stat(p); err != nil {
+				return fmt.Errorf("invalid client certificate: %v", err)
+			}
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return fmt.Errorf("invalid client certificate: %v", err)
+			}
+			paths[i] = abs
+		}
+		inst.args = append(inst.args, "--client-certificate", paths[0], "--client-certificate-key", paths[1])
+		if password != "" {
+			inst.args = append(inst.args, "--client-certificate-password", password)
+		}
+		return nil
+	}
+}
+
+var sensitiveFlags = []string{"--client-certificate-password", "--password", "--video-password", "--ap-password", "--proxy"}
+
+// redactArgs returns a copy of args with the values of sensitive flags replaced.
+func redactArgs(args []string) []string {
+	redacted := slices.Clone(args)
+	for i := 0; i < len(redacted)-1; i++ {
+		if slices.Contains(sensitiveFlags, redacted[i]) {
+			redacted[i+1] = "REDACTED"
+			i++
+		}
+	}
+	return redacted
+}
+
+// WithSourceAddress binds outgoing connections to the given local IP address.
+func WithSourceAddress(ip string) Option {
+	return func(inst *YTDLPInstance) error {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid source address: %s", ip)
+		}
+		inst.args = append(inst.args, "--source-address", ip)
+		return nil
+	}
+}
+
+var cookieBrowsers = []string{"brave", "chrome", "chromium", "edge", "firefox", "opera", "safari", "vivaldi", "whale"}
+
+var cookieKeyrings = []string{"basictext", "gnomekeyring", "kwallet", "kwallet5", "kwallet6"}
+
+// BrowserCookies selects where WithCookiesFromBrowser loads cookies from. Only Browser
+// is required; Container is only supported by Firefox.
+type BrowserCookies struct {
+	Browser   string
+	Keyring   string
+	Profile   string
+	Container string
+}
+
+// spec formats c as BROWSER[+KEYRING][:PROFILE][::CONTAINER].
+func (c BrowserCookies) spec() (string, error) {
+	if !slices.Contains(cookieBrowsers, c.Browser) {
+		return "", fmt.Errorf("unsupported browser: %s", c.Browser)
+	}
+	spec := c.Browser
+	if c.Keyring != "" {
+		if !slices.Contains(cookieKeyrings, strings.ToLower(c.Keyring)) {
+			return "", fmt.Errorf("unsupported keyring: %s", c.Keyring)
+		}
+		spec += "+" + strings.ToUpper(c.Keyring)
+	}
+	if c.Profile != "" {
+		spec += ":" + c.Profile
+	}
+	if c.Container != "" {
+		if c.Browser != "firefox" {
+			return "", errors.New("containers are only supported by firefox")
+		}
+		spec += "::" + c.Container
+	}
+	return spec, nil
+}
+
+func WithCookiesFromBrowser(cookies BrowserCookies) Option {
+	return func(inst *YTDLPInstance) error {
+		spec, err := cookies.spec()
+		if err != nil {
+			return err
+		}
+		inst.args = append(inst.args, "--cookies-from-browser", spec)
+		return nil
+	}
+}
+
+var externalDownloaders = map[string]string{
+	"aria2c": "aria2c",
+	"axel":   "axel",
+	"curl":   "curl",
+	"httpie": "http",
+	"wget":   "wget",
+}
+
+// WithDownloader selects the downloader, optionally restricted to protocols, e.g.
+// "aria2c" or "m3u8:native". External downloaders must be installed.
+func WithDownloader(name string) Option {
+	return func(inst *YTDLPInstance) error {
+		downloader := name
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			downloader = name[i+1:]
+		}
+		if err := inst.checkDownloader(downloader); err != nil {
+			return err
+		}
+		inst.args = append(inst.args, "--downloader", name)
+		return nil
+	}
+}
+
+// WithDownloaderArgs passes args to the given external downloader.
+func WithDownloaderArgs(downloader string, args []string) Option {
+	return func(inst *YTDLPInstance) error {
+		if downloader == "" {
+			return errors.New("empty downloader name")
+		}
+		quoted := make([]string, len(args))
+		for i, a := range args {
+			quoted[i] = shellQuote(a)
+		}
+		inst.args = append(inst.args, "--downloader-args", downloader+":"+strings.Join(quoted, " "))
+		return nil
+	}
+}
+
+func (inst *YTDLPInstance) checkDownloader(name string) error {
+	switch name {
+	case "native":
+		return nil
+	case "ffmpeg":
+		if !inst.HasFFmpeg() {
+			return errors.New("downloader ffmpeg was not found")
+		}
+		return nil
+	}
+	bin, ok := externalDownloaders[name]
+	if !ok {
+		return fmt.Errorf("unsupported downloader: %s", name)
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return fmt.Errorf("downloader %s was not found: %v", name, err)
+	}
+	return nil
+}
+
+// shellQuote quotes s for the POSIX shell-like splitting yt-dlp applies to arguments.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`;&|<>()*?[]#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func HasAria2c() bool {
+	_, err := exec.LookPath("aria2c")
+	return err == nil
+}
+
+// Aria2cOptions holds commonly tuned aria2c settings; zero values keep aria2c's defaults.
+type Aria2cOptions struct {
+	// Connections is the maximum number of connections per server (1-16).
+	Connections int
+	// Split is the number of segments a file is downloaded in.
+	Split int
+	// MinSplitSize is the minimum segment size in bytes, at least 1MiB.
+	MinSplitSize int
+}
+
+// WithAria2c downloads with aria2c, which speeds up segmented downloads considerably.
+func WithAria2c(opts Aria2cOptions) Option {
+	return func(inst *YTDLPInstance) error {
+		args := make([]string, 0)
+		if opts.Connections != 0 {
+			if opts.Connections < 1 || opts.Connections > 16 {
+				return errors.New("aria2c connections must be between 1 and 16")
+			}
+			args = append(args, "-x", strconv.Itoa(opts.Connections))
+		}
+		if opts.Split != 0 {
+			if opts.Split < 1 {
+				return errors.New("aria2c split must be positive")
+			}
+			args = append(args, "-s", strconv.Itoa(opts.Split))
+		}
+		if opts.MinSplitSize != 0 {
+			if opts.MinSplitSize < 1<<20 {
+				return errors.New("aria2c min split size must be at least 1MiB")
+			}
+			args = append(args, "-k", strconv.Itoa(opts.MinSplitSize))
+		}
+		if err := WithDownloader("aria2c")(inst); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return WithDownloaderArgs("aria2c", args)(inst)
+		}
+		return nil
+	}
+}
+
+// ParsableProgressTemplate is a progress template whose output ParseProgressLine and
+// DownloadOptions.OnProgress understand. Other templates passed to WithProgressTemplate
+// silently stop progress reporting unless they keep the "[download] P% of SIZE at
+// SPEED ETA ETA" shape.
+const ParsableProgressTemplate = "download:[download] %(progress._percent_str)s of %(progress._total_bytes_str)s at %(progress._speed_str)s ETA %(progress._eta_str)s"
+
+// WithProgressTemplate customizes yt-dlp's progress lines, see ParsableProgressTemplate.
+func WithProgressTemplate(tmpl string) Option {
+	return func(inst *YTDLPInstance) error {
+		if tmpl == "" {
+			return errors.New("empty progress template")
+		}
+		inst.args = append(inst.args, "--progress-template", tmpl)
+		return nil
+	}
+}
+
+// WithParseMetadata parses fromField (a field name or output template) with toFormat, a
+// regex with named groups such as "(?P<artist>.+?) - (?P<title>.+)" or a template such
+// as "%(artist)s - %(title)s", and sets the matched fields. It can be given multiple times.
+func WithParseMetadata(fromField, toFormat string) Option {
+	return func(inst *YTDLPInstance) error {
+		if fromField == "" || toFormat == "" {
+			return errors.New("parse metadata requires a source and a format")
+		}
+		if !strings.Contains(toFormat, "%(") {
+			if _, err := regexp.Compile(toFormat); err != nil {
+				return fmt.Errorf("invalid parse metadata regex: %v", err)
+			}
+		}
+		inst.args = append(inst.args, "--parse-metadata", fromField+":"+toFormat)
+		return nil
+	}
+}
+
+// WithReplaceInMetadata replaces matches of regex in the comma-separated fields with
+// replacement, which may reference groups as \1. It can be given multiple times.
+// yt-dlp uses Python regexes; the pattern is checked with Go's regexp, which accepts
+// the commonly used subset.
+func WithReplaceInMetadata(field, regex, replacement string) Option {
+	return func(inst *YTDLPInstance) error {
+		if field == "" {
+			return errors.New("empty metadata field")
+		}
+		if _, err := regexp.Compile(regex); err != nil {
+			return fmt.Errorf("invalid replace in metadata regex: %v", err)
+		}
+		inst.args = append(inst.args, "--replace-in-metadata", field, regex, replacement)
+		return nil
+	}
+}
+
+// WithThrottledRate makes yt-dlp re-extract a video when the download speed drops below
+// bytesPerSec, which works around throttled YouTube streams.
+func WithThrottledRate(bytesPerSec int64) Option {
+	return func(inst *YTDLPInstance) error {
+		if bytesPerSec <= 0 {
+			return errors.New("throttled rate must be positive")
+		}
+		inst.args = append(inst.args, "--throttled-rate", strconv.FormatInt(bytesPerSec, 10))
+		return nil
+	}
+}
+
+// WithMinHeight only downloads formats at least h pixels high. Together with
+// WithMaxHeight it becomes the default -f selector, which a -f argument to a single
+// call overrides.
+func WithMinHeight(h int) Option {
+	return func(inst *YTDLPInstance) error {
+		if h <= 0 {
+			return errors.New("minimum height must be positive")
+		}
+		if inst.maxHeight != 0 && h > inst.maxHeight {
+			return fmt.Errorf("minimum height %d exceeds maximum height %d", h, inst.maxHeight)
+		}
+		inst.minHeight = h
+		return nil
+	}
+}
+
+// WithMaxHeight only downloads formats at most h pixels high, see WithMinHeight.
+func WithMaxHeight(h int) Option {
+	return func(inst *YTDLPInstance) error {
+		if h <= 0 {
+			return errors.New("maximum height must be positive")
+		}
+		if inst.minHeight != 0 && h < inst.minHeight {
+			return fmt.Errorf("maximum height %d is below minimum height %d", h, inst.minHeight)
+		}
+		inst.maxHeight = h
+		return nil
+	}
+}
+
+// heightFilter returns the format filter for the height bounds, e.g. "[height<=720]".
+func (inst YTDLPInstance) heightFilter() string {
+	var filter string
+	if inst.minHeight != 0 {
+		filter += fmt.Sprintf("[height>=%d]", inst.minHeight)
+	}
+	if inst.maxHeight != 0 {
+		filter += fmt.Sprintf("[height<=%d]", inst.maxHeight)
+	}
+	return filter
+}
+
+// heightSelector builds a format selector that prefers separate best video and audio
+// streams within the height bounds and falls back to the best combined format.
+func (inst YTDLPInstance) heightSelector() string {
+	filter := inst.heightFilter()
+	if filter == "" {
+		return ""
+	}
+	return "bv*" + filter + "+ba/b" + filter
+}
+
+// WithMaxCapture limits the output DumpStdout keeps in memory to n bytes. Output past
+// the limit is discarded and a note with the number of omitted bytes is appended.
+func WithMaxCapture(n int) Option {
+	return func(inst *YTDLPInstance) error {
+		if n <= 0 {
+			return errors.New("max capture must be positive")
+		}
+		inst.maxCapture = n
+		return nil
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest. It never
+// fails a write, so the process is not blocked or killed by the limit.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	max     int
+	dropped int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := min(len(p), b.max-b.buf.Len())
+	b.buf.Write(p[:n])
+	b.dropped += int64(len(p) - n)
+	return len(p), nil
+}
+
+var sponsorBlockCategories = []string{"sponsor", "intro", "outro", "selfpromo", "preview", "filler", "interaction", "music_offtopic", "chapter", "all"}
+
+// WithSponsorBlockRemove cuts the given SponsorBlock categories, e.g. "sponsor" or
+// "selfpromo", out of downloaded videos using ffmpeg. Cuts are made at the nearest
+// keyframes and can leave glitches at the boundaries; combine it with
+// WithForceKeyframesAtCuts for clean cuts.
+func WithSponsorBlockRemove(categories ...string) Option {
+	return func(inst *YTDLPInstance) error {
+		if len(categories) == 0 {
+			return errors.New("no sponsorblock categories")
+		}
+		for _, c := range categories {
+			if !slices.Contains(sponsorBlockCategories, c) {
+				return fmt.Errorf("unknown sponsorblock category: %s", c)
+			}
+		}
+		inst.args = append(inst.args, "--sponsorblock-remove", strings.Join(categories, ","))
+		return nil
+	}
+}
+
+// WithForceKeyframesAtCuts re-encodes the video around cuts from SponsorBlock removal or
+// --download-sections so they are frame accurate. This is slow and loses some quality
+// at the re-encoded parts.
+func WithForceKeyframesAtCuts() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.args = append(inst.args, "--force-keyframes-at-cuts")
+		return nil
+	}
+}
+
+var subtitleFormats = []string{"srt", "vtt", "ass"}
+
+// WithSubFormat sets the preferred format of downloaded subtitles, e.g. "srt" or a
+// preference list like "srt/vtt/best". Sites only offer some formats; use
+// WithConvertSubs to get a specific one regardless.
+func WithSubFormat(format string) Option {
+	return func(inst *YTDLPInstance) error {
+		for _, f := range strings.Split(format, "/") {
+			if f != "best" && !slices.Contains(subtitleFormats, f) {
+				return fmt.Errorf("unsupported subtitle format: %s", f)
+			}
+		}
+		inst.args = append(inst.args, "--sub-format", format)
+		return nil
+	}
+}
+
+// WithConvertSubs converts downloaded subtitles to format (requires ffmpeg).
+func WithConvertSubs(format string) Option {
+	return func(inst *YTDLPInstance) error {
+		if !slices.Contains(subtitleFormats, format) {
+			return fmt.Errorf("unsupported subtitle format: %s", format)
+		}
+		inst.args = append(inst.args, "--convert-subs", format)
+		return nil
+	}
+}
+
+// WithCmdHook registers fn to be called with every yt-dlp command just before it is
+// started, e.g. to adjust SysProcAttr. fn must not start the command or replace the
+// Stdout or Stderr the library has set up. After fn returns, the library enables a
+// separate process group in SysProcAttr (Setpgid on Unix, CREATE_NEW_PROCESS_GROUP on
+// Windows), which cancellation relies on to stop yt-dlp's children as well; other
+// SysProcAttr settings are kept.
+func WithCmdHook(fn func(*exec.Cmd)) Option {
+	return func(inst *YTDLPInstance) error {
+		if fn == nil {
+			return errors.New("nil command hook")
+		}
+		inst.cmdHook = fn
+		return nil
+	}
+}
+
+// WithWritePages makes yt-dlp dump the pages it fetches during extraction into dir, for
+// debugging extractors or filing bug reports. The dumps can contain cookies, tokens and
+// personal data, so handle them with care. yt-dlp runs in dir: paths the library
+// checks itself are passed on as absolute paths and downloads still go to the current
+// working directory unless WithPaths says otherwise, but other relative paths in
+// arguments resolve against dir.
+func WithWritePages(dir string) Option {
+	return func(inst *YTDLPInstance) error {
+		stat, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("invalid write pages directory: %v", err)
+		}
+		if !stat.IsDir() {
+			return fmt.Errorf("write pages path is not a directory: %s", dir)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		inst.pagesDir = abs
+		inst.args = append(inst.args, "--write-pages")
+		return nil
+	}
+}
+
+var searchPrefixRegexp = regexp.MustCompile(`^[a-z]+search(?:date)?(?:\d+|all)?:`)
+
+// WithURLValidation makes calls taking a URL reject anything but http(s) URLs and
+// search prefixes such as "ytsearch5:" before running yt-dlp. Without it any non-empty
+// string is passed on, which also allows bare video IDs and other inputs yt-dlp accepts.
+func WithURLValidation() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.validateURLs = true
+		return nil
+	}
+}
+
+func (inst YTDLPInstance) checkURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("empty url")
+	}
+	if !inst.validateURLs || searchPrefixRegexp.MatchString(rawURL) {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported url scheme %q in %s", u.Scheme, rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url has no host: %s", rawURL)
+	}
+	return nil
+}
+
+// WithPreferFreeFormats prefers formats with open codecs, such as webm with opus, over
+// others of the same quality. It only affects how formats are ranked, so an explicit
+// format selector still wins.
+func WithPreferFreeFormats() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.args = append(inst.args, "--prefer-free-formats")
+		return nil
+	}
+}
+
+// WithMarkWatched marks downloaded videos as watched on the account, e.g. on YouTube.
+// It needs valid account cookies, see WithCookiesFromBrowser, and has no effect
+// without them.
+func WithMarkWatched() Option {
+	return func(inst *YTDLPInstance) error {
+		inst.args = append(inst.args, "--mark-watched")
+		return nil
+	}
+}
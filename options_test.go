@@ -0,0 +1,29 @@
+package ytdlp
+
+import "testing"
+
+func TestCheckURL(t *testing.T) {
+	inst := YTDLPInstance{validateURLs: true}
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://www.youtube.com/watch?v=abc", false},
+		{"http://example.com/video", false},
+		{"ytsearch:cats", false},
+		{"ytsearch5:cats", false},
+		{"ytsearchall:cats", false},
+		{"ytsearchdate:cats", false},
+		{"ytsearchdate5:cats", false},
+		{"", true},
+		{"file:///etc/passwd", true},
+		{"https://", true},
+		{"ytsearch5date:cats", true},
+		{"abc", true},
+	}
+	for _, tt := range tests {
+		if err := inst.checkURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("checkURL(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
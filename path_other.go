@@ -0,0 +1,7 @@
+//go:build !windows
+
+package ytdlp
+
+func longPath(p string) string {
+	return p
+}
@@ -0,0 +1,30 @@
+package ytdlp
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("extended-length paths only exist on Windows")
+	}
+	long := `C:\` + strings.Repeat(`abcdefghij\`, 30) + "yt-dlp.exe"
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"long absolute", long, `\\?\` + long},
+		{"unc", `\\server\share\yt-dlp.exe`, `\\?\UNC\server\share\yt-dlp.exe`},
+		{"already prefixed", `\\?\C:\bin\yt-dlp.exe`, `\\?\C:\bin\yt-dlp.exe`},
+		{"device path", `\\.\C:\bin\yt-dlp.exe`, `\\.\C:\bin\yt-dlp.exe`},
+		{"short", `C:\bin\yt-dlp.exe`, `C:\bin\yt-dlp.exe`},
+	}
+	for _, tt := range tests {
+		if got := longPath(tt.in); got != tt.want {
+			t.Errorf("%s: longPath(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
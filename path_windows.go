@@ -0,0 +1,31 @@
+//go:build windows
+
+package ytdlp
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is MAX_PATH minus room for an 8.3 file name, the limit Windows applies
+// when creating directories.
+const maxShortPath = 248
+
+// longPath converts p to an extended-length path so it is not subject to MAX_PATH.
+// UNC paths are always converted since some shares reject the short form.
+func longPath(p string) string {
+	if strings.HasPrefix(p, `\\?\`) || strings.HasPrefix(p, `\\.\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	if len(abs) >= maxShortPath {
+		return `\\?\` + abs
+	}
+	return p
+}
@@ -0,0 +1,121 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// playlistItemRegexp matches one comma-separated item of --playlist-items, mirroring
+// yt-dlp's own grammar: an index, a "start-end" or "start:end:step" range, where
+// indices may be negative and the end may be "inf".
+var playlistItemRegexp = regexp.MustCompile(`^([+-]?\d+)?(?:[:-]([+-]?\d+|inf(?:inite)?)?(?::([+-]?\d+))?)?$`)
+
+type ExtractProgress struct {
+	Processed int
+	// Total is the playlist size reported by the site, or 0 if unknown.
+	Total int
+}
+
+// ExtractPlaylist performs a flat extraction of a playlist or channel, decoding entries
+// as yt-dlp prints them. If progress is not nil an ExtractProgress is sent after each
+// entry; sends block until received or ctx is done, so use a buffered channel if the
+// receiver may fall behind. The scan is aborted when ctx is done.
+func (inst YTDLPInstance) ExtractPlaylist(ctx context.Context, url string, progress chan<- ExtractProgress) ([]YTDLPVideoInfo, error) {
+	if err := inst.checkURL(url); err != nil {
+		return nil, err
+	}
+	cmd := inst.command(ctx, url, "--flat-playlist", "-j")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	wait, err := inst.start(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]YTDLPVideoInfo, 0)
+	d := json.NewDecoder(stdout)
+	var decodeErr error
+	for ctx.Err() == nil {
+		var entry struct {
+			YTDLPVideoInfo
+			PlaylistCount int `json:"playlist_count"`
+		}
+		if err := d.Decode(&entry); err != nil {
+			if err != io.EOF {
+				decodeErr = errors.New("failed to decode playlist entry: " + err.Error())
+			}
+			break
+		}
+		entries = append(entries, entry.YTDLPVideoInfo)
+		if progress != nil {
+			select {
+			case progress <- ExtractProgress{Processed: len(entries), Total: entry.PlaylistCount}:
+			case <-ctx.Done():
+			}
+		}
+	}
+	_, _ = io.Copy(io.Discard, stdout)
+	err = wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.New("yt-dlp error: \n" + fmt.Sprint(err) + " | " + stderr.String())
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return entries, nil
+}
+
+type PlaylistInfo struct {
+	ID       string           `json:"id"`
+	Title    string           `json:"title"`
+	Uploader string           `json:"uploader"`
+	Count    int              `json:"playlist_count"`
+	Entries  []YTDLPVideoInfo `json:"entries"`
+}
+
+// GetPlaylistInfo returns a playlist's own metadata together with its entries. Entries
+// come from a flat extraction and only carry the fields the site lists them with.
+func (inst YTDLPInstance) GetPlaylistInfo(url string) (*PlaylistInfo, error) {
+	pi := new(PlaylistInfo)
+	if err := inst.dumpJSON(url, pi, "--flat-playlist"); err != nil {
+		return nil, err
+	}
+	if pi.Entries == nil {
+		pi.Entries = []YTDLPVideoInfo{}
+	}
+	if pi.Count == 0 {
+		pi.Count = len(pi.Entries)
+	}
+	return pi, nil
+}
+
+// ParsePlaylistItems validates a --playlist-items spec such as "1,3-5", "5:", "::2" or
+// "-3:", so mistakes are reported before yt-dlp starts on a large playlist.
+func ParsePlaylistItems(spec string) error {
+	if spec == "" {
+		return errors.New("empty playlist items spec")
+	}
+	for _, item := range strings.Split(spec, ",") {
+		m := playlistItemRegexp.FindStringSubmatch(item)
+		if item == "" || m == nil {
+			return fmt.Errorf("invalid playlist item %q: expected an index, start-end or start:end:step", item)
+		}
+		if step := strings.TrimLeft(m[3], "+-0"); m[3] != "" && step == "" {
+			return fmt.Errorf("invalid playlist item %q: step cannot be zero", item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ytdlp
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// PreflightResult describes the environment the instance runs in.
+type PreflightResult struct {
+	// Executable reports whether the yt-dlp binary exists and can be executed. If not,
+	// Version is empty.
+	Executable bool
+	Version    string
+	// FFmpegVersion is empty if ffmpeg was not found or its version could not be read.
+	FFmpegVersion   string
+	FFmpegAvailable bool
+}
+
+// Preflight checks the yt-dlp binary and ffmpeg in one go, e.g. at startup. A missing
+// binary is reported in the result; an error is only returned if yt-dlp exists but
+// fails to report its version.
+func (inst YTDLPInstance) Preflight(ctx context.Context) (PreflightResult, error) {
+	var res PreflightResult
+	if v, err := FFmpegVersion(inst.ffmpegLocation); err == nil {
+		res.FFmpegAvailable = true
+		res.FFmpegVersion = v
+	} else {
+		res.FFmpegAvailable = inst.HasFFmpeg()
+	}
+	if _, err := exec.LookPath(inst.bPath); err != nil {
+		return res, nil
+	}
+	res.Executable = true
+	out, err := inst.outputContext(ctx, "--version")
+	if err != nil {
+		return res, err
+	}
+	res.Version = strings.TrimSpace(out)
+	return res, nil
+}
@@ -0,0 +1,22 @@
+//go:build !unix && !windows
+
+package ytdlp
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setupProcess(cmd *exec.Cmd) {}
+
+func attachProcess(p *os.Process) {}
+
+func releaseProcess(p *os.Process) {}
+
+func interruptProcess(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
+
+func killProcess(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package ytdlp
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// yt-dlp runs in its own process group so that signals reach the ffmpeg or aria2c
+// processes it spawns as well.
+func setupProcess(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func attachProcess(p *os.Process) {}
+
+func releaseProcess(p *os.Process) {}
+
+// interruptProcess and killProcess signal the process group. If p is not a group leader,
+// e.g. because a command hook replaced SysProcAttr, only p itself is signalled.
+func interruptProcess(p *os.Process) error {
+	if err := syscall.Kill(-p.Pid, syscall.SIGINT); err != syscall.ESRCH {
+		return err
+	}
+	return p.Signal(os.Interrupt)
+}
+
+func killProcess(p *os.Process) error {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != syscall.ESRCH {
+		return err
+	}
+	return p.Kill()
+}
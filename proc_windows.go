@@ -0,0 +1,78 @@
+//go:build windows
+
+package ytdlp
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+var kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+var (
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+// jobs maps the pid of each running yt-dlp process to the job object holding it and
+// its children, so they can be killed together.
+var jobs sync.Map
+
+// Windows has no SIGINT for child processes; the closest equivalent is a CTRL_BREAK
+// event, which requires the child to run in its own process group.
+func setupProcess(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// attachProcess puts p in a new job object, which its children join automatically.
+// Children spawned before the assignment are not covered. Failures leave p to be
+// killed on its own.
+func attachProcess(p *os.Process) {
+	job, _, _ := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return
+	}
+	const access = 0x0100 | 0x0001 // PROCESS_SET_QUOTA | PROCESS_TERMINATE
+	h, err := syscall.OpenProcess(access, false, uint32(p.Pid))
+	if err != nil {
+		syscall.CloseHandle(syscall.Handle(job))
+		return
+	}
+	defer syscall.CloseHandle(h)
+	if r, _, _ := procAssignProcessToJobObject.Call(job, uintptr(h)); r == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		return
+	}
+	jobs.Store(p.Pid, job)
+}
+
+func releaseProcess(p *os.Process) {
+	if job, ok := jobs.LoadAndDelete(p.Pid); ok {
+		syscall.CloseHandle(syscall.Handle(job.(uintptr)))
+	}
+}
+
+func interruptProcess(p *os.Process) error {
+	const ctrlBreakEvent = 1
+	r, _, err := procGenerateConsoleCtrlEvent.Call(ctrlBreakEvent, uintptr(p.Pid))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func killProcess(p *os.Process) error {
+	if job, ok := jobs.Load(p.Pid); ok {
+		if r, _, _ := procTerminateJobObject.Call(job.(uintptr), 1); r != 0 {
+			return nil
+		}
+	}
+	return p.Kill()
+}
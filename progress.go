@@ -0,0 +1,88 @@
+package ytdlp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var progressRegexp = regexp.MustCompile(`^\[download\]\s+(\d+(?:\.\d+)?)%\s+of\s+~?\s*(\S+)(?:\s+in\s+\S+)?(?:\s+at\s+(.+?))?(?:\s+ETA\s+(\S+))?(?:\s+\(.*\))?$`)
+
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+}
+
+type DownloadProgress struct {
+	Percent float64
+	// Raw values as printed by yt-dlp, e.g. "12.34MiB", "1.23MiB/s", "00:42".
+	RawTotal string
+	RawSpeed string
+	RawETA   string
+	// Parsed values; zero when yt-dlp reports them as Unknown or N/A.
+	TotalBytes       int64
+	SpeedBytesPerSec float64
+	ETA              time.Duration
+}
+
+// ParseProgressLine parses a yt-dlp "[download]" progress line. The second return
+// value is false if line is not a progress line.
+func ParseProgressLine(line string) (DownloadProgress, bool) {
+	m := progressRegexp.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return DownloadProgress{}, false
+	}
+	p := DownloadProgress{RawTotal: m[2], RawSpeed: m[3], RawETA: m[4]}
+	p.Percent, _ = strconv.ParseFloat(m[1], 64)
+	if total, ok := parseSize(p.RawTotal); ok {
+		p.TotalBytes = int64(total)
+	}
+	p.SpeedBytesPerSec, _ = parseSize(strings.TrimSuffix(p.RawSpeed, "/s"))
+	p.ETA, _ = parseETA(p.RawETA)
+	return p, true
+}
+
+func parseSize(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i <= 0 {
+		return 0, false
+	}
+	unit, ok := sizeUnits[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * unit, true
+}
+
+// parseETA parses "SS", "MM:SS", "HH:MM:SS" and "D:HH:MM:SS" durations.
+func parseETA(s string) (time.Duration, bool) {
+	parts := strings.Split(s, ":")
+	if s == "" || len(parts) > 4 {
+		return 0, false
+	}
+	multipliers := []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+	var d time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		d += time.Duration(n) * multipliers[len(parts)-1-i]
+	}
+	return d, true
+}
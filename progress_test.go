@@ -0,0 +1,114 @@
+package ytdlp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want DownloadProgress
+	}{
+		{
+			name: "plain",
+			line: "[download]  45.2% of   10.00MiB at    1.50MiB/s ETA 00:04",
+			want: DownloadProgress{Percent: 45.2, RawTotal: "10.00MiB", RawSpeed: "1.50MiB/s", RawETA: "00:04",
+				TotalBytes: 10 << 20, SpeedBytesPerSec: 1.5 * (1 << 20), ETA: 4 * time.Second},
+		},
+		{
+			name: "estimated total with fragments",
+			line: "[download]  12.0% of ~  1.00GiB at  2.00MiB/s ETA 01:02:03 (frag 3/25)",
+			want: DownloadProgress{Percent: 12, RawTotal: "1.00GiB", RawSpeed: "2.00MiB/s", RawETA: "01:02:03",
+				TotalBytes: 1 << 30, SpeedBytesPerSec: 2 << 20, ETA: time.Hour + 2*time.Minute + 3*time.Second},
+		},
+		{
+			name: "unknown speed and eta",
+			line: "[download]   0.5% of   10.00MB at  Unknown B/s ETA Unknown",
+			want: DownloadProgress{Percent: 0.5, RawTotal: "10.00MB", RawSpeed: "Unknown B/s", RawETA: "Unknown",
+				TotalBytes: 10e6},
+		},
+		{
+			name: "not available speed and eta",
+			line: "[download]   3.0% of  500.00KiB at N/A ETA N/A",
+			want: DownloadProgress{Percent: 3, RawTotal: "500.00KiB", RawSpeed: "N/A", RawETA: "N/A",
+				TotalBytes: 500 << 10},
+		},
+		{
+			name: "completion",
+			line: "[download] 100% of   10.00MiB in 00:00:05 at 2.00MiB/s",
+			want: DownloadProgress{Percent: 100, RawTotal: "10.00MiB", RawSpeed: "2.00MiB/s",
+				TotalBytes: 10 << 20, SpeedBytesPerSec: 2 << 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseProgressLine(tt.line)
+			if !ok {
+				t.Fatalf("ParseProgressLine(%q) not recognized", tt.line)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProgressLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProgressLineRejects(t *testing.T) {
+	for _, line := range []string{
+		"[download] Destination: video.mp4",
+		"[youtube] abc: Downloading webpage",
+		"[download] video.mp4 has already been downloaded",
+	} {
+		if _, ok := ParseProgressLine(line); ok {
+			t.Errorf("ParseProgressLine(%q) recognized a non-progress line", line)
+		}
+	}
+}
+
+func TestParseETA(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"42", 42 * time.Second, true},
+		{"01:30", 90 * time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"1:00:00:01", 24*time.Hour + time.Second, true},
+		{"Unknown", 0, false},
+		{"N/A", 0, false},
+		{"", 0, false},
+		{"1:2:3:4:5", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseETA(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseETA(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"512B", 512, true},
+		{"1.50KiB", 1536, true},
+		{"2MB", 2e6, true},
+		{"1.00GiB", 1 << 30, true},
+		{"Unknown B", 0, false},
+		{"N/A", 0, false},
+		{"10XB", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseSize(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
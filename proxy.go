@@ -0,0 +1,72 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"sync/atomic"
+)
+
+var proxySchemes = []string{"http", "https", "socks4", "socks4a", "socks5", "socks5h"}
+
+// ProxyPool hands out proxies for yt-dlp invocations, one per call. It is safe for
+// concurrent use, so a single pool can be shared by instances and goroutines.
+type ProxyPool struct {
+	proxies []string
+	random  bool
+	next    atomic.Uint64
+}
+
+// NewProxyPool returns a pool over proxies such as "socks5://127.0.0.1:1080". Proxies
+// are used in turn, or picked at random if random is set.
+func NewProxyPool(proxies []string, random bool) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, errors.New("empty proxy pool")
+	}
+	for _, p := range proxies {
+		u, err := url.Parse(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %v", err)
+		}
+		if !slices.Contains(proxySchemes, u.Scheme) || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy: %s", p)
+		}
+	}
+	return &ProxyPool{proxies: slices.Clone(proxies), random: random}, nil
+}
+
+// Next returns the proxy for the next invocation.
+func (p *ProxyPool) Next() string {
+	if p.random {
+		return p.proxies[rand.IntN(len(p.proxies))]
+	}
+	return p.proxies[(p.next.Add(1)-1)%uint64(len(p.proxies))]
+}
+
+// WithProxyPool runs every yt-dlp invocation through the next of proxies in turn. The
+// rotation is shared by all goroutines using the instance and by its clones. A --proxy
+// passed to a single call takes precedence.
+func WithProxyPool(proxies []string) Option {
+	return func(inst *YTDLPInstance) error {
+		pool, err := NewProxyPool(proxies, false)
+		if err != nil {
+			return err
+		}
+		inst.proxyPool = pool
+		return nil
+	}
+}
+
+// WithSharedProxyPool is like WithProxyPool but uses an existing pool, e.g. a random
+// one or one shared with other instances.
+func WithSharedProxyPool(pool *ProxyPool) Option {
+	return func(inst *YTDLPInstance) error {
+		if pool == nil {
+			return errors.New("nil proxy pool")
+		}
+		inst.proxyPool = pool
+		return nil
+	}
+}
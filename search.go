@@ -0,0 +1,67 @@
+package ytdlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const videoInfoTemplate = "%(.{id,title,thumbnail,duration})#j"
+
+// SearchProvider is the yt-dlp search prefix of a site.
+type SearchProvider string
+
+const (
+	SearchYouTube     SearchProvider = "ytsearch"
+	SearchYouTubeDate SearchProvider = "ytsearchdate"
+	SearchSoundCloud  SearchProvider = "scsearch"
+	SearchBilibili    SearchProvider = "bilisearch"
+	SearchNicoNico    SearchProvider = "nicosearch"
+)
+
+var searchProviders = []SearchProvider{SearchYouTube, SearchYouTubeDate, SearchSoundCloud, SearchBilibili, SearchNicoNico}
+
+// SearchWith returns up to limit results for query from provider. If query is an
+// http(s) URL it is passed to yt-dlp as is, regardless of provider.
+func (inst YTDLPInstance) SearchWith(provider SearchProvider, query string, limit int) ([]YTDLPVideoInfo, error) {
+	if query == "" {
+		return nil, errors.New("empty query")
+	}
+	if limit < 1 {
+		return nil, errors.New("limit must be positive")
+	}
+	target := query
+	if u, err := url.Parse(query); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		if !slices.Contains(searchProviders, provider) {
+			return nil, fmt.Errorf("unsupported search provider: %s", provider)
+		}
+		target = fmt.Sprintf("%s%d:%s", provider, limit, query)
+	}
+	out, err := inst.output(target, "-s", "-I", fmt.Sprintf(":%d", limit), "-O", videoInfoTemplate)
+	if err != nil {
+		return nil, err
+	}
+	results, err := decodeVideoInfos(out)
+	if err != nil {
+		return nil, errors.New("failed to decode video info: " + err.Error())
+	}
+	return results, nil
+}
+
+func decodeVideoInfos(stdout string) ([]YTDLPVideoInfo, error) {
+	d := json.NewDecoder(strings.NewReader(stdout))
+	infos := make([]YTDLPVideoInfo, 0)
+	for {
+		var vi YTDLPVideoInfo
+		if err := d.Decode(&vi); err == io.EOF {
+			return infos, nil
+		} else if err != nil {
+			return nil, err
+		}
+		infos = append(infos, vi)
+	}
+}
@@ -0,0 +1,128 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrNoSubtitles = errors.New("no subtitles available")
+
+var subtitleTagRegexp = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// GetSubtitleText downloads the subtitles for lang and returns them as plain text with
+// timestamps and markup removed. autoGenerated selects automatic captions instead of
+// manually created subtitles. ErrNoSubtitles is returned if none are available.
+func (inst YTDLPInstance) GetSubtitleText(url, lang string, autoGenerated bool) (string, error) {
+	dir, err := os.MkdirTemp("", "ytdlp-subs-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	writeFlag := "--write-subs"
+	if autoGenerated {
+		writeFlag = "--write-auto-subs"
+	}
+	err = inst.Execute(url, "--skip-download", "--no-playlist", writeFlag, "--sub-langs", lang,
+		"--sub-format", "srt/vtt/best", "-P", dir, "-o", "subtitle")
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if ext != ".srt" && ext != ".vtt" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		return subtitleText(string(b)), nil
+	}
+	if len(entries) > 0 {
+		return "", fmt.Errorf("unsupported subtitle format: %s", entries[0].Name())
+	}
+	return "", ErrNoSubtitles
+}
+
+type SubtitleInfo struct {
+	Lang string
+	Ext  string
+	URL  string
+	Name string
+	// Auto is set for automatically generated captions.
+	Auto bool
+}
+
+// ListSubtitles returns every subtitle track of the video at url, one per language and
+// format, with manual subtitles before automatic captions and each sorted by language.
+func (inst YTDLPInstance) ListSubtitles(url string) ([]SubtitleInfo, error) {
+	type track struct {
+		Ext  string `json:"ext"`
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	var info struct {
+		Subtitles         map[string][]track `json:"subtitles"`
+		AutomaticCaptions map[string][]track `json:"automatic_captions"`
+	}
+	if err := inst.dumpJSON(url, &info, "--skip-download", "--no-playlist"); err != nil {
+		return nil, err
+	}
+	subs := make([]SubtitleInfo, 0)
+	for _, auto := range []bool{false, true} {
+		tracks := info.Subtitles
+		if auto {
+			tracks = info.AutomaticCaptions
+		}
+		for _, lang := range slices.Sorted(maps.Keys(tracks)) {
+			for _, t := range tracks[lang] {
+				subs = append(subs, SubtitleInfo{Lang: lang, Ext: t.Ext, URL: t.URL, Name: t.Name, Auto: auto})
+			}
+		}
+	}
+	return subs, nil
+}
+
+// subtitleText strips cue numbers, timestamps, headers and markup from SRT or WebVTT
+// subtitles. Repeated lines, as produced by rolling automatic captions, are collapsed.
+func subtitleText(subs string) string {
+	lines := make([]string, 0)
+	inBlock := false
+	input := strings.Split(strings.ReplaceAll(subs, "\r\n", "\n"), "\n")
+	for i, line := range input {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			inBlock = false
+			continue
+		case inBlock, strings.Contains(line, "-->"), isCueNumber(line, input[i+1:]):
+			continue
+		case strings.HasPrefix(line, "WEBVTT"), strings.HasPrefix(line, "NOTE"),
+			strings.HasPrefix(line, "STYLE"), strings.HasPrefix(line, "REGION"):
+			inBlock = true
+			continue
+		}
+		line = strings.TrimSpace(html.UnescapeString(subtitleTagRegexp.ReplaceAllString(line, "")))
+		if line != "" && (len(lines) == 0 || lines[len(lines)-1] != line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isCueNumber reports whether line numbers the cue whose timing line follows it, as
+// opposed to a caption that happens to be a number.
+func isCueNumber(line string, rest []string) bool {
+	return strings.Trim(line, "0123456789") == "" && len(rest) > 0 && strings.Contains(rest[0], "-->")
+}
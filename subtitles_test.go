@@ -0,0 +1,14 @@
+package ytdlp
+
+import "testing"
+
+func TestSubtitleText(t *testing.T) {
+	srt := "1\r\n00:00:01,000 --> 00:00:02,000\r\nThe year was\r\n\r\n2\r\n00:00:02,000 --> 00:00:03,000\r\n1984\r\n\r\n"
+	if got, want := subtitleText(srt), "The year was\n1984"; got != want {
+		t.Errorf("subtitleText(srt) = %q, want %q", got, want)
+	}
+	vtt := "WEBVTT\nKind: captions\n\n00:00:01.000 --> 00:00:02.000\n<c>42</c>\n\n00:00:02.000 --> 00:00:03.000\n<c>42</c>\nis the answer\n"
+	if got, want := subtitleText(vtt), "42\nis the answer"; got != want {
+		t.Errorf("subtitleText(vtt) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var templateSpecRegexp = regexp.MustCompile(`^[#0\-+ ]*\d*(?:\.\d+)?[diouxXeEfFgGcrsaBjhlqDSU]`)
+
+// ValidateOutputTemplate checks that every %(field)X in tmpl is closed and followed by
+// a known conversion type. Field names themselves are not checked since extractors can
+// provide arbitrary fields.
+func ValidateOutputTemplate(tmpl string) error {
+	if tmpl == "" {
+		return errors.New("empty output template")
+	}
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '%' || i+1 >= len(tmpl) {
+			continue
+		}
+		if tmpl[i+1] == '%' {
+			i++
+			continue
+		}
+		if tmpl[i+1] != '(' {
+			continue
+		}
+		depth := 0
+		end := -1
+		for j := i + 1; j < len(tmpl) && end < 0; j++ {
+			switch tmpl[j] {
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return fmt.Errorf("unbalanced parentheses in output template at offset %d", i)
+		}
+		spec := templateSpecRegexp.FindString(tmpl[end+1:])
+		if spec == "" {
+			return fmt.Errorf("missing or unknown conversion type after %s", tmpl[i:end+1])
+		}
+		i = end + len(spec)
+	}
+	return nil
+}
+
+// WithOutputTemplate sets the output filename template, see ValidateOutputTemplate.
+func WithOutputTemplate(tmpl string) Option {
+	return func(inst *YTDLPInstance) error {
+		if err := ValidateOutputTemplate(tmpl); err != nil {
+			return err
+		}
+		inst.args = append(inst.args, "-o", tmpl)
+		return nil
+	}
+}
+
+// WithOutputFor sets the output template for one type of file, such as "thumbnail",
+// "subtitle" or "infojson", leaving the template for the media itself unchanged.
+func WithOutputFor(mediaType, tmpl string) Option {
+	return func(inst *YTDLPInstance) error {
+		if !slices.Contains(outputTypes, mediaType) {
+			return fmt.Errorf("invalid output type: %s", mediaType)
+		}
+		if err := ValidateOutputTemplate(tmpl); err != nil {
+			return err
+		}
+		inst.args = append(inst.args, "-o", mediaType+":"+tmpl)
+		return nil
+	}
+}
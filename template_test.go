@@ -0,0 +1,33 @@
+package ytdlp
+
+import "testing"
+
+func TestValidateOutputTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{"%(title)s.%(ext)s", false},
+		{"%(playlist_index)03d - %(title)s.%(ext)s", false},
+		{"%(title)-20.10s", false},
+		{"%(upload_date>%Y-%m-%d)s %(title)s", false},
+		{"%(title|(untitled))s", false},
+		{"%(title,(fallback))s", false},
+		{"100%% %(title)s", false},
+		{"%%(title)", false},
+		{"plain.mp4", false},
+		{"", true},
+		{"%(title.%(ext)s", true},
+		{"%(title)s.%(ext", true},
+		{"%(title)", true},
+		{"%(title).%(ext)s", true},
+		{"%(title)y", true},
+		{"%(title)5", true},
+	}
+	for _, tt := range tests {
+		err := ValidateOutputTemplate(tt.tmpl)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateOutputTemplate(%q) = %v, want error %v", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
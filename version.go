@@ -0,0 +1,181 @@
+package ytdlp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionRegexp = regexp.MustCompile(`^(\d{4})\.(\d{1,2})\.(\d{1,2})(?:\.(\d+))?(.*)$`)
+
+// Version is a date-based yt-dlp version of the form YYYY.MM.DD[.N], where N is a
+// revision for stable releases or a build time for nightly builds.
+type Version struct {
+	Year     int
+	Month    int
+	Day      int
+	Revision int
+	// Suffix holds any trailing pre-release marker such as "dev0".
+	Suffix string
+}
+
+// ParseVersion parses a yt-dlp version string. A leading channel such as
+// "nightly@" is ignored.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	if _, after, ok := strings.Cut(s, "@"); ok {
+		s = after
+	}
+	m := versionRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid version: %s", s)
+	}
+	v := Version{Suffix: strings.TrimLeft(m[5], ".-+")}
+	v.Year, _ = strconv.Atoi(m[1])
+	v.Month, _ = strconv.Atoi(m[2])
+	v.Day, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.Revision, _ = strconv.Atoi(m[4])
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is older than, equal to or
+// newer than other. A version with a suffix is older than the same version without.
+func (v Version) Compare(other Version) int {
+	if c := cmp.Compare(v.Year, other.Year); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Month, other.Month); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Day, other.Day); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Revision, other.Revision); c != 0 {
+		return c
+	}
+	switch {
+	case v.Suffix == other.Suffix:
+		return 0
+	case v.Suffix == "":
+		return 1
+	case other.Suffix == "":
+		return -1
+	}
+	// compare "dev9" and "dev10" by their numbers, not as strings
+	prefix, n := splitSuffix(v.Suffix)
+	otherPrefix, otherN := splitSuffix(other.Suffix)
+	if c := cmp.Compare(prefix, otherPrefix); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(n, otherN); c != 0 {
+		return c
+	}
+	return cmp.Compare(v.Suffix, other.Suffix)
+}
+
+// splitSuffix splits a suffix such as "dev10" into its text and trailing number.
+func splitSuffix(s string) (string, int) {
+	prefix := strings.TrimRight(s, "0123456789")
+	n, _ := strconv.Atoi(s[len(prefix):])
+	return prefix, n
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%04d.%02d.%02d", v.Year, v.Month, v.Day)
+	if v.Revision != 0 {
+		s += "." + strconv.Itoa(v.Revision)
+	}
+	if v.Suffix != "" {
+		s += "." + v.Suffix
+	}
+	return s
+}
+
+func (inst YTDLPInstance) Version() (string, error) {
+	out, err := inst.output("--version")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// EnsureMinVersion makes sure the binary at path is at least minVersion, downloading
+// the latest release from GitHub if it is missing or older. It returns the version
+// that is installed afterwards.
+func EnsureMinVersion(path, minVersion string) (string, error) {
+	minV, err := ParseVersion(minVersion)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		inst, _ := NewInstance(path)
+		if v, err := inst.Version(); err == nil {
+			if pv, err := ParseVersion(v); err == nil && pv.Compare(minV) >= 0 {
+				return v, nil
+			}
+		}
+	}
+	v, err := DownloadLatestFromGithubVersioned(path)
+	if err != nil {
+		return "", err
+	}
+	if pv, err := ParseVersion(v); err != nil || pv.Compare(minV) < 0 {
+		return "", fmt.Errorf("latest release %s does not satisfy minimum version %s", v, minVersion)
+	}
+	return v, nil
+}
+
+type ReleaseChannel int
+
+const (
+	ChannelUnknown ReleaseChannel = iota
+	ChannelStable
+	ChannelNightly
+	ChannelMaster
+)
+
+var channelNames = map[ReleaseChannel]string{
+	ChannelUnknown: "unknown",
+	ChannelStable:  "stable",
+	ChannelNightly: "nightly",
+	ChannelMaster:  "master",
+}
+
+var bannerRegexp = regexp.MustCompile(`yt-dlp version (\w+)@`)
+
+func (c ReleaseChannel) String() string {
+	return channelNames[c]
+}
+
+// Channel reports the release channel of the binary. It reads the verbose banner and
+// falls back to guessing from the version, where nightly builds carry a build time.
+func (inst YTDLPInstance) Channel() (ReleaseChannel, error) {
+	// without a URL yt-dlp exits with an error after printing the banner
+	out, _ := inst.combinedOutput(inst.command(context.Background(), "-v"))
+	if m := bannerRegexp.FindSubmatch(out); m != nil {
+		for c, name := range channelNames {
+			if name == string(m[1]) {
+				return c, nil
+			}
+		}
+		return ChannelUnknown, nil
+	}
+	s, err := inst.Version()
+	if err != nil {
+		return ChannelUnknown, err
+	}
+	v, err := ParseVersion(s)
+	if err != nil {
+		return ChannelUnknown, err
+	}
+	if v.Revision > 99 {
+		return ChannelNightly, nil
+	}
+	return ChannelStable, nil
+}
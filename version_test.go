@@ -0,0 +1,63 @@
+package ytdlp
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"2024.08.06", Version{Year: 2024, Month: 8, Day: 6}},
+		{"2023.03.04.1", Version{Year: 2023, Month: 3, Day: 4, Revision: 1}},
+		{"nightly@2024.08.06.232620", Version{Year: 2024, Month: 8, Day: 6, Revision: 232620}},
+		{"2024.08.06.dev10", Version{Year: 2024, Month: 8, Day: 6, Suffix: "dev10"}},
+		{" 2024.8.6\n", Version{Year: 2024, Month: 8, Day: 6}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Errorf("ParseVersion(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "latest", "24.08.06", "2024-08-06"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) accepted an invalid version", in)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2024.08.06", "2024.08.06", 0},
+		{"2024.08.06", "2024.07.30", 1},
+		{"2023.12.30", "2024.01.01", -1},
+		{"2023.03.04.1", "2023.03.04", 1},
+		{"2023.03.04.2", "2023.03.04.10", -1},
+		{"nightly@2024.08.06.232620", "2024.08.06", 1},
+		{"nightly@2024.08.06.232620", "master@2024.08.06.232620", 0},
+		{"2024.08.06.dev0", "2024.08.06", -1},
+		{"2024.08.06.dev9", "2024.08.06.dev10", -1},
+		{"2024.08.06.dev10", "2024.08.06.dev9", 1},
+		{"2024.08.06.beta1", "2024.08.06.dev2", -1},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}